@@ -0,0 +1,134 @@
+package secp256k1
+
+import (
+	"crypto/elliptic"
+	"math/big"
+)
+
+// curveParams are the parameters of the secp256k1 elliptic curve. Note that
+// the generic methods of elliptic.CurveParams assume that a = -3, so they
+// must not be used for secp256k1, for which a = 0.
+var curveParams = &elliptic.CurveParams{
+	P:       pInt,
+	N:       nInt,
+	B:       big.NewInt(7),
+	Gx:      hexInt("79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798"),
+	Gy:      hexInt("483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8"),
+	BitSize: 256,
+	Name:    "secp256k1",
+}
+
+// curve implements elliptic.Curve using Point.
+type curve struct{}
+
+// Curve returns an implementation of elliptic.Curve for secp256k1 that uses
+// this library for the curve arithmetic, so that code written against
+// crypto/elliptic or crypto/ecdsa can use it. As with the curves in
+// crypto/elliptic, the point at infinity is represented by the coordinates
+// (0, 0), and the methods panic if they are given coordinates that do not
+// represent a curve point. Scalars are reduced modulo N.
+func Curve() elliptic.Curve {
+	return curve{}
+}
+
+// Params implements the elliptic.Curve interface.
+func (curve) Params() *elliptic.CurveParams {
+	return curveParams
+}
+
+// IsOnCurve implements the elliptic.Curve interface. It returns false for
+// coordinates that are negative or not less than P.
+func (curve) IsOnCurve(x, y *big.Int) bool {
+	_, ok := pointFromAffine(x, y)
+	return ok && !(x.Sign() == 0 && y.Sign() == 0)
+}
+
+// Add implements the elliptic.Curve interface.
+func (curve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	p := mustPointFromAffine(x1, y1, "Add")
+	q := mustPointFromAffine(x2, y2, "Add")
+	p.Add(&p, &q)
+	return affineFromPoint(&p)
+}
+
+// Double implements the elliptic.Curve interface.
+func (curve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
+	p := mustPointFromAffine(x1, y1, "Double")
+	p.Add(&p, &p)
+	return affineFromPoint(&p)
+}
+
+// ScalarMult implements the elliptic.Curve interface. The scalar is given in
+// big endian form and can have any length.
+func (curve) ScalarMult(x1, y1 *big.Int, k []byte) (*big.Int, *big.Int) {
+	p := mustPointFromAffine(x1, y1, "ScalarMult")
+	scaleBytes(&p, &p, k)
+	return affineFromPoint(&p)
+}
+
+// ScalarBaseMult implements the elliptic.Curve interface. The scalar is given
+// in big endian form and can have any length.
+func (curve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	var p Point
+	scalar := NewFnFromInt(new(big.Int).SetBytes(k))
+	defer scalar.Clear()
+
+	p.BaseExp(&scalar)
+	return affineFromPoint(&p)
+}
+
+// scaleBytes computes the scalar multiplication of the given curve point by the
+// given big endian scalar reduced modulo N, and stores the result in dst. The
+// point can be the point at infinity, which (0, 0) is mapped to.
+func scaleBytes(dst, p *Point, k []byte) {
+	scalar := NewFnFromInt(new(big.Int).SetBytes(k))
+	defer scalar.Clear()
+
+	dst.ScaleExt(p, &scalar)
+}
+
+// pointFromAffine returns the curve point with the given affine coordinates,
+// where (0, 0) represents the point at infinity, and false if the coordinates
+// do not represent a curve point.
+func pointFromAffine(x, y *big.Int) (Point, bool) {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return NewPointInfinity(), true
+	}
+	if x.Sign() < 0 || x.Cmp(pInt) >= 0 || y.Sign() < 0 || y.Cmp(pInt) >= 0 {
+		return Point{}, false
+	}
+
+	var fx, fy Fp
+	fx.SetInt(x)
+	fy.SetInt(y)
+	p, err := NewPointFromXY(&fx, &fy)
+	return p, err == nil
+}
+
+// mustPointFromAffine is the same as pointFromAffine, except that it panics if
+// the coordinates do not represent a curve point.
+func mustPointFromAffine(x, y *big.Int, method string) Point {
+	p, ok := pointFromAffine(x, y)
+	if !ok {
+		panic("secp256k1: " + method + " was called on an invalid point")
+	}
+	return p
+}
+
+// affineFromPoint returns the affine coordinates of the given curve point,
+// where the point at infinity is represented by (0, 0).
+func affineFromPoint(p *Point) (*big.Int, *big.Int) {
+	x, y, err := p.XY()
+	if err != nil {
+		return new(big.Int), new(big.Int)
+	}
+	return x.Int(), y.Int()
+}
+
+func hexInt(s string) *big.Int {
+	x, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("invalid hex integer: " + s)
+	}
+	return x
+}
@@ -0,0 +1,59 @@
+package secp256k1
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Curve internals", func() {
+	trials := 100
+
+	randomScalar := func() []byte {
+		k := make([]byte, 32)
+		if _, err := rand.Read(k); err != nil {
+			panic(err)
+		}
+		return k
+	}
+
+	It("should map (0, 0) to the point at infinity and back", func() {
+		p, ok := pointFromAffine(new(big.Int), new(big.Int))
+		Expect(ok).To(BeTrue())
+		Expect(p.IsInfinity()).To(BeTrue())
+
+		x, y := affineFromPoint(&p)
+		Expect(x.Sign()).To(Equal(0))
+		Expect(y.Sign()).To(Equal(0))
+	})
+
+	It("should scale the point at infinity to the point at infinity", func() {
+		for i := 0; i < trials; i++ {
+			var p Point
+			inf := NewPointInfinity()
+			scaleBytes(&p, &inf, randomScalar())
+			Expect(p.IsInfinity()).To(BeTrue())
+
+			// The result behaves as the identity when added to other points.
+			q := RandomPoint()
+			var sum Point
+			sum.Add(&p, &q)
+			Expect(sum.Eq(&q)).To(BeTrue())
+		}
+	})
+
+	It("should scale curve points correctly", func() {
+		for i := 0; i < trials; i++ {
+			var actual, expected Point
+			p := RandomPoint()
+			k := randomScalar()
+			scaleBytes(&actual, &p, k)
+
+			scalar := NewFnFromInt(new(big.Int).SetBytes(k))
+			expected.Scale(&p, &scalar)
+			Expect(actual.Eq(&expected)).To(BeTrue())
+		}
+	})
+})
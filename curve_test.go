@@ -0,0 +1,164 @@
+package secp256k1_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/dustinxie/ecc"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/secp256k1"
+)
+
+var _ = Describe("Curve", func() {
+	trials := 100
+
+	curve := Curve()
+	reference := ecc.P256k1()
+
+	randomScalar := func() []byte {
+		k := make([]byte, 32)
+		if _, err := rand.Read(k); err != nil {
+			panic(err)
+		}
+		return k
+	}
+
+	It("should have the secp256k1 parameters", func() {
+		params, expected := curve.Params(), reference.Params()
+		Expect(params.P.Cmp(expected.P)).To(Equal(0))
+		Expect(params.N.Cmp(expected.N)).To(Equal(0))
+		Expect(params.B.Cmp(expected.B)).To(Equal(0))
+		Expect(params.Gx.Cmp(expected.Gx)).To(Equal(0))
+		Expect(params.Gy.Cmp(expected.Gy)).To(Equal(0))
+		Expect(params.BitSize).To(Equal(256))
+		Expect(params.Name).To(Equal("secp256k1"))
+	})
+
+	It("should compute scalar multiples of the generator correctly", func() {
+		for i := 0; i < trials; i++ {
+			k := randomScalar()
+			x, y := curve.ScalarBaseMult(k)
+			ex, ey := reference.ScalarBaseMult(k)
+			Expect(x.Cmp(ex)).To(Equal(0))
+			Expect(y.Cmp(ey)).To(Equal(0))
+			Expect(curve.IsOnCurve(x, y)).To(BeTrue())
+		}
+
+		// Scalars are reduced modulo N.
+		n := curve.Params().N
+		x, y := curve.ScalarBaseMult(n.Bytes())
+		Expect(x.Sign()).To(Equal(0))
+		Expect(y.Sign()).To(Equal(0))
+
+		long := new(big.Int).Add(new(big.Int).Lsh(n, 100), big.NewInt(1))
+		x, y = curve.ScalarBaseMult(long.Bytes())
+		Expect(x.Cmp(curve.Params().Gx)).To(Equal(0))
+		Expect(y.Cmp(curve.Params().Gy)).To(Equal(0))
+	})
+
+	It("should compute scalar multiples of curve points correctly", func() {
+		for i := 0; i < trials; i++ {
+			bx, by := reference.ScalarBaseMult(randomScalar())
+			k := randomScalar()
+			x, y := curve.ScalarMult(bx, by, k)
+			ex, ey := reference.ScalarMult(bx, by, k)
+			Expect(x.Cmp(ex)).To(Equal(0))
+			Expect(y.Cmp(ey)).To(Equal(0))
+		}
+
+		x, y := curve.ScalarMult(new(big.Int), new(big.Int), randomScalar())
+		Expect(x.Sign()).To(Equal(0))
+		Expect(y.Sign()).To(Equal(0))
+	})
+
+	It("should add and double curve points correctly", func() {
+		for i := 0; i < trials; i++ {
+			x1, y1 := reference.ScalarBaseMult(randomScalar())
+			x2, y2 := reference.ScalarBaseMult(randomScalar())
+
+			x, y := curve.Add(x1, y1, x2, y2)
+			ex, ey := reference.Add(x1, y1, x2, y2)
+			Expect(x.Cmp(ex)).To(Equal(0))
+			Expect(y.Cmp(ey)).To(Equal(0))
+
+			x, y = curve.Double(x1, y1)
+			ex, ey = reference.Double(x1, y1)
+			Expect(x.Cmp(ex)).To(Equal(0))
+			Expect(y.Cmp(ey)).To(Equal(0))
+
+			x, y = curve.Add(x1, y1, x1, y1)
+			Expect(x.Cmp(ex)).To(Equal(0))
+			Expect(y.Cmp(ey)).To(Equal(0))
+
+			// The point at infinity is represented by (0, 0).
+			zero := new(big.Int)
+			x, y = curve.Add(x1, y1, zero, zero)
+			Expect(x.Cmp(x1)).To(Equal(0))
+			Expect(y.Cmp(y1)).To(Equal(0))
+
+			x, y = curve.Add(x1, y1, x1, new(big.Int).Sub(curve.Params().P, y1))
+			Expect(x.Sign()).To(Equal(0))
+			Expect(y.Sign()).To(Equal(0))
+		}
+	})
+
+	It("should identify points on the curve", func() {
+		p := curve.Params().P
+		for i := 0; i < trials; i++ {
+			x, y := curve.ScalarBaseMult(randomScalar())
+			Expect(curve.IsOnCurve(x, y)).To(BeTrue())
+
+			Expect(curve.IsOnCurve(x, new(big.Int).Add(y, big.NewInt(1)))).To(BeFalse())
+			Expect(curve.IsOnCurve(x, new(big.Int).Add(y, p))).To(BeFalse())
+			Expect(curve.IsOnCurve(x, new(big.Int).Sub(y, p))).To(BeFalse())
+		}
+
+		Expect(curve.IsOnCurve(new(big.Int), new(big.Int))).To(BeFalse())
+	})
+
+	It("should panic when given an invalid point", func() {
+		x, y := curve.ScalarBaseMult(randomScalar())
+		y.Add(y, big.NewInt(1))
+		Expect(func() { curve.Add(x, y, x, y) }).To(Panic())
+		Expect(func() { curve.Double(x, y) }).To(Panic())
+		Expect(func() { curve.ScalarMult(x, y, randomScalar()) }).To(Panic())
+	})
+
+	It("should work with crypto/ecdsa", func() {
+		for i := 0; i < trials; i++ {
+			priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+
+			hash := sha256.Sum256(randomScalar())
+			r, s, err := ecdsa.Sign(rand.Reader, priv, hash[:])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ecdsa.Verify(&priv.PublicKey, hash[:], r, s)).To(BeTrue())
+
+			// Signatures should also verify using the reference curve.
+			pub := ecdsa.PublicKey{Curve: reference, X: priv.X, Y: priv.Y}
+			Expect(ecdsa.Verify(&pub, hash[:], r, s)).To(BeTrue())
+
+			hash[0] ^= 1
+			Expect(ecdsa.Verify(&priv.PublicKey, hash[:], r, s)).To(BeFalse())
+		}
+	})
+})
+
+func BenchmarkCurveScalarMult(b *testing.B) {
+	curve := Curve()
+	k := make([]byte, 32)
+	if _, err := rand.Read(k); err != nil {
+		panic(err)
+	}
+	x, y := curve.ScalarBaseMult(k)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		curve.ScalarMult(x, y, k)
+	}
+}
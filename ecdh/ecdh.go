@@ -0,0 +1,91 @@
+// Package ecdh implements elliptic curve Diffie-Hellman key agreement over the
+// secp256k1 curve, modelled on the ecdh module of libsecp256k1.
+package ecdh
+
+import (
+	"crypto/sha256"
+	"errors"
+
+	"github.com/renproject/secp256k1"
+)
+
+// HashFunc derives a shared secret from the big endian bytes of the x and y
+// coordinates of the shared point.
+type HashFunc func(x, y *[32]byte) ([]byte, error)
+
+// HashSHA256 is the default hash function, and is the same as the default hash
+// function used by libsecp256k1. It returns the SHA-256 hash of the 33 byte
+// compressed encoding of the shared point.
+func HashSHA256(x, y *[32]byte) ([]byte, error) {
+	version := 0x02 | (y[31] & 0x01)
+
+	h := sha256.New()
+	h.Write([]byte{version})
+	h.Write(x[:])
+	return h.Sum(nil), nil
+}
+
+// SharedPoint computes the shared point between the given private key and the
+// public key of the peer. An error is returned if the private key is zero, or
+// if the public key is the point at infinity or is not on the curve.
+//
+// Panics: This function will panic if either argument is nil.
+func SharedPoint(priv *secp256k1.Fn, pub *secp256k1.Point) (secp256k1.Point, error) {
+	if priv == nil {
+		panic("expected first argument to not be nil")
+	}
+	if pub == nil {
+		panic("expected second argument to not be nil")
+	}
+
+	if priv.IsZero() {
+		return secp256k1.Point{}, errors.New("invalid private key: zero")
+	}
+	if pub.IsInfinity() {
+		return secp256k1.Point{}, errors.New("invalid public key: point at infinity")
+	}
+	if !pub.IsOnCurve() {
+		return secp256k1.Point{}, errors.New("invalid public key: not on the curve")
+	}
+
+	// The group has prime order, so the product of a non zero scalar and a
+	// point that is not the point at infinity is never the point at infinity.
+	var shared secp256k1.Point
+	shared.Scale(pub, priv)
+	return shared, nil
+}
+
+// SharedSecret computes the shared point between the given private key and the
+// public key of the peer, and returns the result of applying the given hash
+// function to its coordinates. If the hash function is nil, HashSHA256 is
+// used. An error is returned in the same cases as SharedPoint, or if the hash
+// function returns an error.
+//
+// Panics: This function will panic if either the private key or public key is
+// nil.
+func SharedSecret(priv *secp256k1.Fn, pub *secp256k1.Point, hash HashFunc) ([]byte, error) {
+	if hash == nil {
+		hash = HashSHA256
+	}
+
+	shared, err := SharedPoint(priv, pub)
+	if err != nil {
+		return nil, err
+	}
+
+	var x, y [32]byte
+	defer func() {
+		x = [32]byte{}
+		y = [32]byte{}
+	}()
+
+	fx, fy, err := shared.XY()
+	if err != nil {
+		// This can not happen for a valid public key and non zero private key.
+		return nil, err
+	}
+	fx.PutB32(x[:])
+	fy.PutB32(y[:])
+
+	return hash(&x, &y)
+}
@@ -0,0 +1,13 @@
+package ecdh_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestEcdh(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Ecdh Suite")
+}
@@ -0,0 +1,191 @@
+package ecdh_test
+
+import (
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/dustinxie/ecc"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/renproject/secp256k1"
+	. "github.com/renproject/secp256k1/ecdh"
+)
+
+var _ = Describe("ECDH", func() {
+	trials := 100
+
+	curve := ecc.P256k1()
+
+	randomKeyPair := func() (secp256k1.Fn, secp256k1.Point) {
+		var pub secp256k1.Point
+		priv := secp256k1.RandomFn()
+		pub.BaseExp(&priv)
+		return priv, pub
+	}
+
+	Context("when computing the shared point", func() {
+		It("should compute the same point for both parties", func() {
+			for i := 0; i < trials; i++ {
+				privA, pubA := randomKeyPair()
+				privB, pubB := randomKeyPair()
+
+				sharedA, err := SharedPoint(&privA, &pubB)
+				Expect(err).ToNot(HaveOccurred())
+				sharedB, err := SharedPoint(&privB, &pubA)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sharedA.Eq(&sharedB)).To(BeTrue())
+			}
+		})
+
+		It("should agree with another implementation", func() {
+			for i := 0; i < trials; i++ {
+				priv := secp256k1.RandomFn()
+				pub := secp256k1.RandomPoint()
+
+				shared, err := SharedPoint(&priv, &pub)
+				Expect(err).ToNot(HaveOccurred())
+
+				px, py, err := pub.XY()
+				Expect(err).ToNot(HaveOccurred())
+				var bs [32]byte
+				priv.PutB32(bs[:])
+				expectedX, expectedY := curve.ScalarMult(px.Int(), py.Int(), bs[:])
+
+				x, y, err := shared.XY()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(x.Int().Cmp(expectedX)).To(Equal(0))
+				Expect(y.Int().Cmp(expectedY)).To(Equal(0))
+			}
+		})
+
+		It("should return an error for a zero private key", func() {
+			var zero secp256k1.Fn
+			pub := secp256k1.RandomPoint()
+			_, err := SharedPoint(&zero, &pub)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error for the point at infinity", func() {
+			priv := secp256k1.RandomFn()
+			inf := secp256k1.NewPointInfinity()
+			_, err := SharedPoint(&priv, &inf)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error for points not on the curve", func() {
+			var pub secp256k1.Point
+			priv := secp256k1.RandomFn()
+			for i := 0; i < trials; i++ {
+				x, y := secp256k1.RandomFp(), secp256k1.RandomFp()
+				pub.SetXY(&x, &y)
+				_, err := SharedPoint(&priv, &pub)
+				Expect(err).To(HaveOccurred())
+			}
+		})
+
+		It("should panic when any argument is nil", func() {
+			priv, pub := randomKeyPair()
+			Expect(func() { SharedPoint(nil, &pub) }).To(Panic())
+			Expect(func() { SharedPoint(&priv, nil) }).To(Panic())
+		})
+	})
+
+	Context("when computing the shared secret", func() {
+		It("should compute the same secret for both parties", func() {
+			for i := 0; i < trials; i++ {
+				privA, pubA := randomKeyPair()
+				privB, pubB := randomKeyPair()
+
+				secretA, err := SharedSecret(&privA, &pubB, nil)
+				Expect(err).ToNot(HaveOccurred())
+				secretB, err := SharedSecret(&privB, &pubA, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(secretA).To(Equal(secretB))
+			}
+		})
+
+		It("should hash the compressed shared point by default", func() {
+			var bs [secp256k1.PointSizeMarshalled]byte
+			for i := 0; i < trials; i++ {
+				privA, _ := randomKeyPair()
+				_, pubB := randomKeyPair()
+
+				shared, err := SharedPoint(&privA, &pubB)
+				Expect(err).ToNot(HaveOccurred())
+				x, y, err := shared.XY()
+				Expect(err).ToNot(HaveOccurred())
+				if y.IsEven() {
+					bs[0] = 0x02
+				} else {
+					bs[0] = 0x03
+				}
+				x.PutB32(bs[1:])
+				expected := sha256.Sum256(bs[:])
+
+				secret, err := SharedSecret(&privA, &pubB, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(secret).To(Equal(expected[:]))
+
+				secret, err = SharedSecret(&privA, &pubB, HashSHA256)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(secret).To(Equal(expected[:]))
+			}
+		})
+
+		It("should pass the coordinates of the shared point to the hash function", func() {
+			identity := func(x, y *[32]byte) ([]byte, error) {
+				return append(x[:], y[:]...), nil
+			}
+
+			var expected [64]byte
+			for i := 0; i < trials; i++ {
+				privA, _ := randomKeyPair()
+				_, pubB := randomKeyPair()
+
+				shared, err := SharedPoint(&privA, &pubB)
+				Expect(err).ToNot(HaveOccurred())
+				x, y, err := shared.XY()
+				Expect(err).ToNot(HaveOccurred())
+				x.PutB32(expected[:32])
+				y.PutB32(expected[32:])
+
+				secret, err := SharedSecret(&privA, &pubB, identity)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(secret).To(Equal(expected[:]))
+			}
+		})
+
+		It("should return an error when the hash function fails", func() {
+			failing := func(x, y *[32]byte) ([]byte, error) {
+				return nil, errors.New("failed")
+			}
+
+			priv, pub := randomKeyPair()
+			_, err := SharedSecret(&priv, &pub, failing)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error for invalid keys", func() {
+			var zero secp256k1.Fn
+			priv, pub := randomKeyPair()
+			inf := secp256k1.NewPointInfinity()
+
+			_, err := SharedSecret(&zero, &pub, nil)
+			Expect(err).To(HaveOccurred())
+			_, err = SharedSecret(&priv, &inf, nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+func BenchmarkSharedSecret(b *testing.B) {
+	var pub secp256k1.Point
+	priv := secp256k1.RandomFn()
+	pub.BaseExp(&priv)
+
+	for i := 0; i < b.N; i++ {
+		_, _ = SharedSecret(&priv, &pub, nil)
+	}
+}
@@ -0,0 +1,222 @@
+// Package ecdsa implements ECDSA signing and verification over the secp256k1
+// curve, using deterministic nonces as defined in RFC 6979.
+package ecdsa
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/renproject/secp256k1"
+)
+
+// SignatureSizeMarshalled is the number of bytes needed to represent a
+// marshalled signature.
+const SignatureSizeMarshalled int = 2 * secp256k1.FnSizeMarshalled
+
+// Signature represents an ECDSA signature. Signatures produced by Sign always
+// have an S value that is not high (that is, not greater than N/2).
+type Signature struct {
+	R, S secp256k1.Fn
+}
+
+// Sign signs the given message hash with the given private key. The nonce is
+// derived deterministically from the private key and hash using RFC 6979, and
+// the S value of the resulting signature is normalised to not be high. An
+// error is returned if the private key is zero.
+func Sign(priv *secp256k1.Fn, hash [32]byte) (Signature, error) {
+	sig, _, err := sign(priv, hash)
+	return sig, err
+}
+
+// sign computes the signature of the given hash and additionally returns the
+// recovery id of the signature, which is a number in the range [0, 3] where
+// the first bit is set if the y coordinate of the nonce point is odd and the
+// second bit is set if its x coordinate was greater than or equal to N.
+func sign(priv *secp256k1.Fn, hash [32]byte) (Signature, byte, error) {
+	if priv.IsZero() {
+		return Signature{}, 0, errors.New("invalid private key: zero")
+	}
+
+	var key, nonce [32]byte
+	priv.PutB32(key[:])
+	defer func() {
+		key = [32]byte{}
+		nonce = [32]byte{}
+	}()
+
+	var m, k, kInv, s secp256k1.Fn
+	var R secp256k1.Point
+	m.SetB32(hash[:])
+	defer func() {
+		k.Clear()
+		kInv.Clear()
+	}()
+
+	for counter := uint(0); ; counter++ {
+		nonceRFC6979(&nonce, &hash, &key, counter)
+
+		// SetB32SecKey is not used here because it copies its argument to
+		// memory that is never cleared or freed.
+		overflow := k.SetB32(nonce[:])
+		if overflow || k.IsZero() {
+			continue
+		}
+
+		R.BaseExp(&k)
+		r, overflow := xModN(&R)
+		if r.IsZero() {
+			continue
+		}
+
+		// s = k^-1 * (m + r*d)
+		s.Mul(&r, priv)
+		s.Add(&s, &m)
+		kInv.InverseInvar(&k)
+		s.Mul(&s, &kInv)
+		if s.IsZero() {
+			continue
+		}
+
+		var recid byte
+		if !R.HasEvenY() {
+			recid |= 1
+		}
+		if overflow {
+			recid |= 2
+		}
+
+		if s.IsHigh() {
+			s.Negate(&s)
+			recid ^= 1
+		}
+
+		k.Clear()
+		kInv.Clear()
+		return Signature{R: r, S: s}, recid, nil
+	}
+}
+
+// Verify returns true if the given signature is a valid signature of the given
+// message hash for the given public key, and false otherwise. Like
+// libsecp256k1, signatures with a high S value are rejected, so signatures
+// produced by other implementations may need to be normalised first.
+func Verify(pub *secp256k1.Point, hash [32]byte, sig *Signature) bool {
+	if sig.R.IsZero() || sig.S.IsZero() || sig.S.IsHigh() {
+		return false
+	}
+	if pub.IsInfinity() || !pub.IsOnCurve() {
+		return false
+	}
+
+	var m, sInv, u1, u2 secp256k1.Fn
+	m.SetB32(hash[:])
+	sInv.Inverse(&sig.S)
+	u1.Mul(&m, &sInv)
+	u2.Mul(&sig.R, &sInv)
+
+	// R = u1*G + u2*pub
+	var R secp256k1.Point
+	R.DoubleBaseExpVar(&u1, pub, &u2)
+	if R.IsInfinity() {
+		return false
+	}
+
+	r, _ := xModN(&R)
+	return r.Eq(&sig.R)
+}
+
+// xModN returns the x coordinate of the given point reduced modulo N, and
+// whether the x coordinate was greater than or equal to N. The point must not
+// be the point at infinity.
+func xModN(p *secp256k1.Point) (secp256k1.Fn, bool) {
+	var bs [32]byte
+	var r secp256k1.Fn
+
+	x, _, err := p.XY()
+	if err != nil {
+		panic(fmt.Sprintf("unexpected point at infinity: %v", err))
+	}
+	x.PutB32(bs[:])
+	overflow := r.SetB32(bs[:])
+	return r, overflow
+}
+
+// IsNormalized returns true if the S value of the signature is not high, and
+// false otherwise.
+func (sig *Signature) IsNormalized() bool {
+	return !sig.S.IsHigh()
+}
+
+// Normalize replaces the S value of the signature by its negation if it is
+// high, so that the signature will be accepted by Verify. Both forms of the
+// signature are valid for the same message and key. It returns true if the
+// signature was modified, and false otherwise.
+func (sig *Signature) Normalize() bool {
+	if !sig.S.IsHigh() {
+		return false
+	}
+	sig.S.Negate(&sig.S)
+	return true
+}
+
+// PutBytes stores the signature in the given byte slice in the 64 byte
+// compact form, which is the big endian bytes of R followed by the big endian
+// bytes of S.
+//
+// Panics: If the byte slice has length less than 64, this function will panic.
+func (sig *Signature) PutBytes(dst []byte) {
+	if len(dst) < SignatureSizeMarshalled {
+		panic(fmt.Sprintf("invalid slice length: length needs to be at least 64, got %v", len(dst)))
+	}
+
+	sig.R.PutB32(dst[:32])
+	sig.S.PutB32(dst[32:64])
+}
+
+// SetBytes sets the signature from the given byte slice in the 64 byte compact
+// form. It will return an error if either R or S are greater than or equal to
+// N.
+//
+// Panics: If the byte slice has length less than 64, this function will panic.
+func (sig *Signature) SetBytes(bs []byte) error {
+	if len(bs) < SignatureSizeMarshalled {
+		panic(fmt.Sprintf("invalid slice length: length needs to be at least 64, got %v", len(bs)))
+	}
+
+	overflowR := sig.R.SetB32(bs[:32])
+	overflowS := sig.S.SetB32(bs[32:64])
+	if overflowR || overflowS {
+		*sig = Signature{}
+		return errors.New("invalid signature data")
+	}
+	return nil
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (sig Signature) SizeHint() int { return SignatureSizeMarshalled }
+
+// Marshal implements the surge.Marshaler interface.
+func (sig Signature) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := sig.R.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return sig.S.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface. As with SetBytes, an
+// error is returned if either R or S are greater than or equal to N, rather
+// than reducing them, so that each signature has only one encoding.
+func (sig *Signature) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := sig.R.UnmarshalStrict(buf, rem)
+	if err != nil {
+		*sig = Signature{}
+		return buf, rem, err
+	}
+	buf, rem, err = sig.S.UnmarshalStrict(buf, rem)
+	if err != nil {
+		*sig = Signature{}
+		return buf, rem, err
+	}
+	return buf, rem, nil
+}
@@ -0,0 +1,13 @@
+package ecdsa_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestEcdsa(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Ecdsa Suite")
+}
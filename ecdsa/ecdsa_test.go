@@ -0,0 +1,363 @@
+package ecdsa_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/dustinxie/ecc"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/renproject/secp256k1"
+	. "github.com/renproject/secp256k1/ecdsa"
+)
+
+var _ = Describe("ECDSA", func() {
+	trials := 100
+
+	params := ecc.P256k1()
+
+	randomHash := func() [32]byte {
+		var hash [32]byte
+		if _, err := rand.Read(hash[:]); err != nil {
+			panic(err)
+		}
+		return hash
+	}
+
+	randomKeyPair := func() (secp256k1.Fn, secp256k1.Point) {
+		var pub secp256k1.Point
+		priv := secp256k1.RandomFn()
+		pub.BaseExp(&priv)
+		return priv, pub
+	}
+
+	fnFromHex := func(str string) secp256k1.Fn {
+		var x secp256k1.Fn
+		bs, err := hex.DecodeString(str)
+		if err != nil || len(bs) != 32 {
+			panic("invalid hex scalar")
+		}
+		x.SetB32(bs)
+		return x
+	}
+
+	stdPublicKey := func(pub *secp256k1.Point) *ecdsa.PublicKey {
+		x, y, err := pub.XY()
+		if err != nil {
+			panic(err)
+		}
+		return &ecdsa.PublicKey{Curve: params, X: x.Int(), Y: y.Int()}
+	}
+
+	Context("when signing", func() {
+		It("should produce the RFC 6979 test vector signatures", func() {
+			// Test vectors for deterministic signatures over secp256k1 using
+			// RFC 6979 with SHA-256 and low S normalisation.
+			vectors := []struct {
+				priv, msg, r, s string
+			}{
+				{
+					"0000000000000000000000000000000000000000000000000000000000000001",
+					"Satoshi Nakamoto",
+					"934b1ea10a4b3c1757e2b0c017d0b6143ce3c9a7e6a4a49860d7a6ab210ee3d8",
+					"2442ce9d2b916064108014783e923ec36b49743e2ffa1c4496f01a512aafd9e5",
+				},
+				{
+					"0000000000000000000000000000000000000000000000000000000000000001",
+					"All those moments will be lost in time, like tears in rain. Time to die...",
+					"8600dbd41e348fe5c9465ab92d23e3db8b98b873beecd930736488696438cb6b",
+					"547fe64427496db33bf66019dacbf0039c04199abb0122918601db38a72cfc21",
+				},
+				{
+					"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140",
+					"Satoshi Nakamoto",
+					"fd567d121db66e382991534ada77a6bd3106f0a1098c231e47993447cd6af2d0",
+					"6b39cd0eb1bc8603e159ef5c20a5c8ad685a45b06ce9bebed3f153d10d93bed5",
+				},
+				{
+					"f8b8af8ce3c7cca5e300d33939540c10d45ce001b8f252bfbc57ba0342904181",
+					"Alan Turing",
+					"7063ae83e7f62bbb171798131b4a0564b956930092b33b07b395615d9ec7e15c",
+					"58dfcc1e00a35e1572f366ffe34ba0fc47db1e7189759b9fb233c5b05ab388ea",
+				},
+			}
+
+			for _, vector := range vectors {
+				priv := fnFromHex(vector.priv)
+				hash := sha256.Sum256([]byte(vector.msg))
+				r, s := fnFromHex(vector.r), fnFromHex(vector.s)
+
+				sig, err := Sign(&priv, hash)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sig.R.Eq(&r)).To(BeTrue())
+				Expect(sig.S.Eq(&s)).To(BeTrue())
+			}
+		})
+
+		It("should be deterministic", func() {
+			for i := 0; i < trials; i++ {
+				priv, _ := randomKeyPair()
+				hash := randomHash()
+
+				sig1, err := Sign(&priv, hash)
+				Expect(err).ToNot(HaveOccurred())
+				sig2, err := Sign(&priv, hash)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(sig1.R.Eq(&sig2.R)).To(BeTrue())
+				Expect(sig1.S.Eq(&sig2.S)).To(BeTrue())
+			}
+		})
+
+		It("should produce normalised signatures", func() {
+			for i := 0; i < trials; i++ {
+				priv, _ := randomKeyPair()
+				sig, err := Sign(&priv, randomHash())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sig.IsNormalized()).To(BeTrue())
+				Expect(sig.S.IsHigh()).To(BeFalse())
+			}
+		})
+
+		It("should return an error for the zero private key", func() {
+			zero := secp256k1.Fn{}
+			_, err := Sign(&zero, randomHash())
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should produce signatures accepted by another implementation", func() {
+			for i := 0; i < trials; i++ {
+				priv, pub := randomKeyPair()
+				hash := randomHash()
+
+				sig, err := Sign(&priv, hash)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ecc.Verify(stdPublicKey(&pub), hash[:], sig.R.Int(), sig.S.Int())).To(BeTrue())
+			}
+		})
+	})
+
+	Context("when verifying", func() {
+		It("should accept valid signatures", func() {
+			for i := 0; i < trials; i++ {
+				priv, pub := randomKeyPair()
+				hash := randomHash()
+
+				sig, err := Sign(&priv, hash)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(Verify(&pub, hash, &sig)).To(BeTrue())
+			}
+		})
+
+		It("should reject signatures for a different hash or key", func() {
+			for i := 0; i < trials; i++ {
+				priv, pub := randomKeyPair()
+				_, otherPub := randomKeyPair()
+				hash := randomHash()
+
+				sig, err := Sign(&priv, hash)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(Verify(&pub, randomHash(), &sig)).To(BeFalse())
+				Expect(Verify(&otherPub, hash, &sig)).To(BeFalse())
+			}
+		})
+
+		It("should reject modified signatures", func() {
+			one := secp256k1.NewFnFromU16(1)
+			for i := 0; i < trials; i++ {
+				priv, pub := randomKeyPair()
+				hash := randomHash()
+
+				sig, err := Sign(&priv, hash)
+				Expect(err).ToNot(HaveOccurred())
+
+				modified := sig
+				modified.R.Add(&modified.R, &one)
+				Expect(Verify(&pub, hash, &modified)).To(BeFalse())
+
+				modified = sig
+				modified.S.Add(&modified.S, &one)
+				Expect(Verify(&pub, hash, &modified)).To(BeFalse())
+			}
+		})
+
+		It("should reject signatures with zero values", func() {
+			priv, pub := randomKeyPair()
+			hash := randomHash()
+			sig, err := Sign(&priv, hash)
+			Expect(err).ToNot(HaveOccurred())
+
+			modified := sig
+			modified.R.Clear()
+			Expect(Verify(&pub, hash, &modified)).To(BeFalse())
+
+			modified = sig
+			modified.S.Clear()
+			Expect(Verify(&pub, hash, &modified)).To(BeFalse())
+		})
+
+		It("should reject the point at infinity as a public key", func() {
+			priv, _ := randomKeyPair()
+			hash := randomHash()
+			sig, err := Sign(&priv, hash)
+			Expect(err).ToNot(HaveOccurred())
+
+			inf := secp256k1.NewPointInfinity()
+			Expect(Verify(&inf, hash, &sig)).To(BeFalse())
+		})
+
+		It("should reject high S signatures until they are normalised", func() {
+			for i := 0; i < trials; i++ {
+				priv, pub := randomKeyPair()
+				hash := randomHash()
+
+				sig, err := Sign(&priv, hash)
+				Expect(err).ToNot(HaveOccurred())
+
+				sig.S.Negate(&sig.S)
+				Expect(sig.IsNormalized()).To(BeFalse())
+				Expect(Verify(&pub, hash, &sig)).To(BeFalse())
+
+				Expect(sig.Normalize()).To(BeTrue())
+				Expect(Verify(&pub, hash, &sig)).To(BeTrue())
+				Expect(sig.Normalize()).To(BeFalse())
+			}
+		})
+
+		It("should accept normalised signatures from another implementation", func() {
+			for i := 0; i < trials; i++ {
+				priv, pub := randomKeyPair()
+				hash := randomHash()
+
+				stdPriv := &ecdsa.PrivateKey{PublicKey: *stdPublicKey(&pub), D: priv.Int()}
+				r, s, _, err := ecc.Sign(rand.Reader, stdPriv, hash[:])
+				Expect(err).ToNot(HaveOccurred())
+
+				var sig Signature
+				var bs [64]byte
+				r.FillBytes(bs[:32])
+				s.FillBytes(bs[32:])
+				Expect(sig.SetBytes(bs[:])).To(Succeed())
+				sig.Normalize()
+
+				Expect(Verify(&pub, hash, &sig)).To(BeTrue())
+			}
+		})
+	})
+
+	Context("when marshalling", func() {
+		It("should be equal after converting to and from bytes", func() {
+			var bs [SignatureSizeMarshalled]byte
+			var after Signature
+			for i := 0; i < trials; i++ {
+				priv, _ := randomKeyPair()
+				before, err := Sign(&priv, randomHash())
+				Expect(err).ToNot(HaveOccurred())
+
+				before.PutBytes(bs[:])
+				Expect(after.SetBytes(bs[:])).To(Succeed())
+				Expect(after.R.Eq(&before.R)).To(BeTrue())
+				Expect(after.S.Eq(&before.S)).To(BeTrue())
+			}
+		})
+
+		It("should return an error when decoding values that are out of range", func() {
+			// N and N + 1 would otherwise be reduced to 0 and 1.
+			outOfRange := []*big.Int{
+				new(big.Int).Set(params.Params().N),
+				new(big.Int).Add(params.Params().N, big.NewInt(1)),
+			}
+			for _, x := range outOfRange {
+				for _, offset := range []int{0, 32} {
+					var bs [SignatureSizeMarshalled]byte
+					bs[31], bs[63] = 1, 1
+					x.FillBytes(bs[offset : offset+32])
+
+					var sig Signature
+					Expect(sig.SetBytes(bs[:])).ToNot(Succeed())
+
+					_, _, err := sig.Unmarshal(bs[:], 2*secp256k1.FnSize)
+					Expect(err).To(HaveOccurred())
+					Expect(sig.R.IsZero()).To(BeTrue())
+					Expect(sig.S.IsZero()).To(BeTrue())
+				}
+			}
+		})
+
+		It("should be equal after marshaling and unmarshaling with surge", func() {
+			var bs [SignatureSizeMarshalled]byte
+			var after Signature
+			for i := 0; i < trials; i++ {
+				priv, _ := randomKeyPair()
+				before, err := Sign(&priv, randomHash())
+				Expect(err).ToNot(HaveOccurred())
+
+				tail, rem, err := before.Marshal(bs[:], before.SizeHint())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rem).To(Equal(0))
+				Expect(len(tail)).To(Equal(0))
+
+				tail, rem, err = after.Unmarshal(bs[:], 2*secp256k1.FnSize)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rem).To(Equal(0))
+				Expect(len(tail)).To(Equal(0))
+
+				Expect(after.R.Eq(&before.R)).To(BeTrue())
+				Expect(after.S.Eq(&before.S)).To(BeTrue())
+			}
+		})
+
+		It("should return an error when marshalling with a buffer that is too small", func() {
+			var bs [SignatureSizeMarshalled - 1]byte
+			var sig Signature
+			for i := 0; i < SignatureSizeMarshalled-1; i++ {
+				_, _, err := sig.Marshal(bs[:i], SignatureSizeMarshalled)
+				Expect(err).To(HaveOccurred())
+			}
+		})
+
+		It("should return an error when unmarshalling with a buffer that is too small", func() {
+			var bs [SignatureSizeMarshalled - 1]byte
+			var sig Signature
+			for i := 0; i < SignatureSizeMarshalled-1; i++ {
+				_, _, err := sig.Unmarshal(bs[:i], 2*secp256k1.FnSize)
+				Expect(err).To(HaveOccurred())
+			}
+		})
+
+		It("should panic when the slice length is too small", func() {
+			var sig Signature
+			var bs [SignatureSizeMarshalled - 1]byte
+			for i := 0; i < SignatureSizeMarshalled-1; i++ {
+				Expect(func() { sig.PutBytes(bs[:i]) }).To(Panic())
+				Expect(func() { sig.SetBytes(bs[:i]) }).To(Panic())
+			}
+		})
+	})
+})
+
+func BenchmarkSign(b *testing.B) {
+	priv := secp256k1.RandomFn()
+	hash := [32]byte{}
+
+	for i := 0; i < b.N; i++ {
+		_, _ = Sign(&priv, hash)
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	var pub secp256k1.Point
+	priv := secp256k1.RandomFn()
+	pub.BaseExp(&priv)
+	hash := [32]byte{}
+	sig, _ := Sign(&priv, hash)
+
+	for i := 0; i < b.N; i++ {
+		_ = Verify(&pub, hash, &sig)
+	}
+}
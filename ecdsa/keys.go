@@ -0,0 +1,411 @@
+package ecdsa
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/renproject/secp256k1"
+)
+
+var (
+	// oidPublicKeyECDSA is the object identifier of elliptic curve public keys
+	// as defined in RFC 5480.
+	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+
+	// oidSecp256k1 is the object identifier of the secp256k1 curve as defined
+	// in SEC 2.
+	oidSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+// ecPrivateKey is the ASN.1 structure of a private key as defined in SEC 1 and
+// RFC 5915.
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// pkcs8 is the ASN.1 structure of an unencrypted private key as defined in
+// PKCS #8 and RFC 5208.
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// subjectPublicKeyInfo is the ASN.1 structure of a public key as defined in
+// RFC 5280.
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// PrivateKey represents a secp256k1 private key, which is a non zero scalar.
+type PrivateKey struct {
+	scalar secp256k1.Fn
+}
+
+// PublicKey represents a secp256k1 public key, which is a curve point other
+// than the point at infinity.
+type PublicKey struct {
+	point secp256k1.Point
+}
+
+// GeneratePrivateKey returns a new random private key, or an error if there
+// was an error reading random bytes.
+func GeneratePrivateKey() (PrivateKey, error) {
+	for {
+		scalar, err := secp256k1.RandomFnNoPanic()
+		if err != nil {
+			return PrivateKey{}, err
+		}
+		if !scalar.IsZero() {
+			return PrivateKey{scalar: scalar}, nil
+		}
+	}
+}
+
+// NewPrivateKey returns the private key with the given scalar, or an error if
+// the scalar is zero.
+func NewPrivateKey(scalar *secp256k1.Fn) (PrivateKey, error) {
+	if scalar.IsZero() {
+		return PrivateKey{}, errors.New("invalid private key: zero")
+	}
+	return PrivateKey{scalar: *scalar}, nil
+}
+
+// Scalar returns the scalar of the private key.
+func (priv *PrivateKey) Scalar() secp256k1.Fn {
+	return priv.scalar
+}
+
+// PublicKey returns the public key corresponding to the private key.
+func (priv *PrivateKey) PublicKey() PublicKey {
+	var pub PublicKey
+	pub.point.BaseExp(&priv.scalar)
+	return pub
+}
+
+// Eq returns true if the two private keys are equal, and false otherwise.
+func (priv *PrivateKey) Eq(other *PrivateKey) bool {
+	return priv.scalar.Eq(&other.scalar)
+}
+
+// Clear sets the scalar of the private key to zero, which leaves the private
+// key in an invalid state.
+func (priv *PrivateKey) Clear() {
+	priv.scalar.Clear()
+}
+
+// NewPublicKey returns the public key with the given point, or an error if the
+// point is the point at infinity or is not on the curve.
+func NewPublicKey(point *secp256k1.Point) (PublicKey, error) {
+	if point.IsInfinity() {
+		return PublicKey{}, errors.New("invalid public key: point at infinity")
+	}
+	p := *point
+	if !p.IsOnCurve() {
+		return PublicKey{}, errors.New("invalid public key: point is not on the curve")
+	}
+	return PublicKey{point: p}, nil
+}
+
+// Point returns the curve point of the public key.
+func (pub *PublicKey) Point() secp256k1.Point {
+	return pub.point
+}
+
+// Eq returns true if the two public keys are equal, and false otherwise.
+func (pub *PublicKey) Eq(other *PublicKey) bool {
+	return pub.point.Eq(&other.point)
+}
+
+// MarshalSEC1 returns the SEC 1 ECPrivateKey DER encoding of the private key,
+// which includes the curve and the uncompressed public key. This is the
+// encoding used by OpenSSL for "EC PRIVATE KEY" PEM blocks.
+func (priv *PrivateKey) MarshalSEC1() ([]byte, error) {
+	return priv.marshalSEC1(oidSecp256k1)
+}
+
+// MarshalPKCS8 returns the PKCS #8 DER encoding of the private key.
+func (priv *PrivateKey) MarshalPKCS8() ([]byte, error) {
+	params, err := asn1.Marshal(oidSecp256k1)
+	if err != nil {
+		return nil, err
+	}
+
+	// The curve is given by the algorithm parameters, so it is omitted from
+	// the inner private key.
+	inner, err := priv.marshalSEC1(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(pkcs8{
+		Version: 0,
+		Algo: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPublicKeyECDSA,
+			Parameters: asn1.RawValue{FullBytes: params},
+		},
+		PrivateKey: inner,
+	})
+}
+
+// marshalSEC1 returns the SEC 1 ECPrivateKey DER encoding of the private key,
+// including the given curve object identifier if it is not nil.
+func (priv *PrivateKey) marshalSEC1(oid asn1.ObjectIdentifier) ([]byte, error) {
+	if priv.scalar.IsZero() {
+		return nil, errors.New("invalid private key: zero")
+	}
+
+	scalar := make([]byte, secp256k1.FnSizeMarshalled)
+	defer zero(scalar)
+	priv.scalar.PutB32(scalar)
+
+	pub := priv.PublicKey()
+	pubBytes := make([]byte, secp256k1.PointSizeSEC1Uncompressed)
+	pub.point.PutSEC1Uncompressed(pubBytes)
+
+	return asn1.Marshal(ecPrivateKey{
+		Version:       1,
+		PrivateKey:    scalar,
+		NamedCurveOID: oid,
+		PublicKey:     asn1.BitString{Bytes: pubBytes, BitLength: 8 * len(pubBytes)},
+	})
+}
+
+// ParseSEC1PrivateKey parses a private key from its SEC 1 ECPrivateKey DER
+// encoding. An error is returned if the encoding is malformed, if the curve is
+// not secp256k1, if the scalar is zero or not less than N, or if the encoding
+// includes a public key that does not correspond to the private key.
+func ParseSEC1PrivateKey(der []byte) (PrivateKey, error) {
+	return parseSEC1(der, nil)
+}
+
+// ParsePKCS8PrivateKey parses a private key from its unencrypted PKCS #8 DER
+// encoding. An error is returned if the encoding is malformed, if the key is
+// not a secp256k1 elliptic curve key, or if the inner private key is invalid.
+func ParsePKCS8PrivateKey(der []byte) (PrivateKey, error) {
+	var key pkcs8
+	rest, err := asn1.Unmarshal(der, &key)
+	if err != nil {
+		return PrivateKey{}, fmt.Errorf("invalid pkcs8 private key: %v", err)
+	}
+	if len(rest) != 0 {
+		return PrivateKey{}, errors.New("invalid pkcs8 private key: trailing data")
+	}
+	if key.Version != 0 {
+		return PrivateKey{}, fmt.Errorf("invalid pkcs8 private key: unsupported version %v", key.Version)
+	}
+	if err := checkAlgorithm(&key.Algo); err != nil {
+		return PrivateKey{}, err
+	}
+	return parseSEC1(key.PrivateKey, oidSecp256k1)
+}
+
+// parseSEC1 parses a SEC 1 ECPrivateKey DER encoding. If the curve is known
+// from the context, it is given as the object identifier, and the encoding
+// does not need to include it.
+func parseSEC1(der []byte, oid asn1.ObjectIdentifier) (PrivateKey, error) {
+	var key ecPrivateKey
+	rest, err := asn1.Unmarshal(der, &key)
+	if err != nil {
+		return PrivateKey{}, fmt.Errorf("invalid ec private key: %v", err)
+	}
+	defer zero(key.PrivateKey)
+	if len(rest) != 0 {
+		return PrivateKey{}, errors.New("invalid ec private key: trailing data")
+	}
+	if key.Version != 1 {
+		return PrivateKey{}, fmt.Errorf("invalid ec private key: unsupported version %v", key.Version)
+	}
+	if key.NamedCurveOID == nil && oid == nil {
+		return PrivateKey{}, errors.New("invalid ec private key: missing curve")
+	}
+	if key.NamedCurveOID != nil && !key.NamedCurveOID.Equal(oidSecp256k1) {
+		return PrivateKey{}, fmt.Errorf("invalid ec private key: unsupported curve %v", key.NamedCurveOID)
+	}
+	if len(key.PrivateKey) > secp256k1.FnSizeMarshalled {
+		return PrivateKey{}, fmt.Errorf("invalid ec private key: expected at most %v bytes, got %v", secp256k1.FnSizeMarshalled, len(key.PrivateKey))
+	}
+
+	// Some encoders omit leading zero bytes of the scalar.
+	scalar := make([]byte, secp256k1.FnSizeMarshalled)
+	defer zero(scalar)
+	copy(scalar[len(scalar)-len(key.PrivateKey):], key.PrivateKey)
+
+	var priv PrivateKey
+	if err := priv.scalar.SetB32Canonical(scalar); err != nil || priv.scalar.IsZero() {
+		return PrivateKey{}, errors.New("invalid ec private key: scalar is zero or not less than N")
+	}
+
+	if len(key.PublicKey.Bytes) != 0 {
+		pub, err := parsePublicKeyBits(&key.PublicKey)
+		if err != nil {
+			return PrivateKey{}, err
+		}
+		expected := priv.PublicKey()
+		if !pub.Eq(&expected) {
+			return PrivateKey{}, errors.New("invalid ec private key: public key does not match")
+		}
+	}
+
+	return priv, nil
+}
+
+// MarshalPKIX returns the PKIX SubjectPublicKeyInfo DER encoding of the public
+// key, with the point in uncompressed form.
+func (pub *PublicKey) MarshalPKIX() ([]byte, error) {
+	if _, err := NewPublicKey(&pub.point); err != nil {
+		return nil, err
+	}
+
+	params, err := asn1.Marshal(oidSecp256k1)
+	if err != nil {
+		return nil, err
+	}
+
+	pubBytes := make([]byte, secp256k1.PointSizeSEC1Uncompressed)
+	pub.point.PutSEC1Uncompressed(pubBytes)
+
+	return asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPublicKeyECDSA,
+			Parameters: asn1.RawValue{FullBytes: params},
+		},
+		PublicKey: asn1.BitString{Bytes: pubBytes, BitLength: 8 * len(pubBytes)},
+	})
+}
+
+// ParsePKIXPublicKey parses a public key from its PKIX SubjectPublicKeyInfo
+// DER encoding. Both the compressed and uncompressed forms of the point are
+// accepted. An error is returned if the encoding is malformed, if the key is
+// not a secp256k1 elliptic curve key, or if the point is not valid.
+func ParsePKIXPublicKey(der []byte) (PublicKey, error) {
+	var info subjectPublicKeyInfo
+	rest, err := asn1.Unmarshal(der, &info)
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("invalid pkix public key: %v", err)
+	}
+	if len(rest) != 0 {
+		return PublicKey{}, errors.New("invalid pkix public key: trailing data")
+	}
+	if err := checkAlgorithm(&info.Algorithm); err != nil {
+		return PublicKey{}, err
+	}
+	return parsePublicKeyBits(&info.PublicKey)
+}
+
+// parsePublicKeyBits parses a public key from a bit string containing the
+// SEC 1 encoding of the point.
+func parsePublicKeyBits(bits *asn1.BitString) (PublicKey, error) {
+	if bits.BitLength != 8*len(bits.Bytes) {
+		return PublicKey{}, errors.New("invalid public key: bit string is not a whole number of bytes")
+	}
+
+	var point secp256k1.Point
+	if err := point.SetSEC1(bits.Bytes); err != nil {
+		return PublicKey{}, fmt.Errorf("invalid public key: %v", err)
+	}
+	return NewPublicKey(&point)
+}
+
+// checkAlgorithm returns an error if the given algorithm identifier does not
+// identify a secp256k1 elliptic curve key.
+func checkAlgorithm(algo *pkix.AlgorithmIdentifier) error {
+	if !algo.Algorithm.Equal(oidPublicKeyECDSA) {
+		return fmt.Errorf("unsupported key algorithm %v", algo.Algorithm)
+	}
+
+	var oid asn1.ObjectIdentifier
+	rest, err := asn1.Unmarshal(algo.Parameters.FullBytes, &oid)
+	if err != nil || len(rest) != 0 {
+		return errors.New("invalid elliptic curve parameters")
+	}
+	if !oid.Equal(oidSecp256k1) {
+		return fmt.Errorf("unsupported curve %v", oid)
+	}
+	return nil
+}
+
+const (
+	pemTypeSEC1  = "EC PRIVATE KEY"
+	pemTypePKCS8 = "PRIVATE KEY"
+	pemTypePKIX  = "PUBLIC KEY"
+)
+
+// MarshalSEC1PEM returns the SEC 1 encoding of the private key in an
+// "EC PRIVATE KEY" PEM block.
+func (priv *PrivateKey) MarshalSEC1PEM() ([]byte, error) {
+	der, err := priv.MarshalSEC1()
+	if err != nil {
+		return nil, err
+	}
+	defer zero(der)
+	return pem.EncodeToMemory(&pem.Block{Type: pemTypeSEC1, Bytes: der}), nil
+}
+
+// MarshalPKCS8PEM returns the PKCS #8 encoding of the private key in a
+// "PRIVATE KEY" PEM block.
+func (priv *PrivateKey) MarshalPKCS8PEM() ([]byte, error) {
+	der, err := priv.MarshalPKCS8()
+	if err != nil {
+		return nil, err
+	}
+	defer zero(der)
+	return pem.EncodeToMemory(&pem.Block{Type: pemTypePKCS8, Bytes: der}), nil
+}
+
+// MarshalPKIXPEM returns the PKIX encoding of the public key in a
+// "PUBLIC KEY" PEM block.
+func (pub *PublicKey) MarshalPKIXPEM() ([]byte, error) {
+	der, err := pub.MarshalPKIX()
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemTypePKIX, Bytes: der}), nil
+}
+
+// ParsePrivateKeyPEM parses a private key from the first PEM block in the
+// given data, which can be either an "EC PRIVATE KEY" block containing the
+// SEC 1 encoding or a "PRIVATE KEY" block containing the PKCS #8 encoding.
+func ParsePrivateKeyPEM(data []byte) (PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return PrivateKey{}, errors.New("no pem block found")
+	}
+	defer zero(block.Bytes)
+
+	switch block.Type {
+	case pemTypeSEC1:
+		return ParseSEC1PrivateKey(block.Bytes)
+	case pemTypePKCS8:
+		return ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return PrivateKey{}, fmt.Errorf("unexpected pem block type %q", block.Type)
+	}
+}
+
+// ParsePublicKeyPEM parses a public key from the first PEM block in the given
+// data, which must be a "PUBLIC KEY" block containing the PKIX encoding.
+func ParsePublicKeyPEM(data []byte) (PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return PublicKey{}, errors.New("no pem block found")
+	}
+	if block.Type != pemTypePKIX {
+		return PublicKey{}, fmt.Errorf("unexpected pem block type %q", block.Type)
+	}
+	return ParsePKIXPublicKey(block.Bytes)
+}
+
+// zero sets all of the given bytes to zero.
+func zero(bs []byte) {
+	for i := range bs {
+		bs[i] = 0
+	}
+}
@@ -0,0 +1,35 @@
+package ecdsa
+
+/*
+
+#include "../secp256k1/include/secp256k1.h"
+#include "../secp256k1/src/util.h"
+#include "../secp256k1/src/hash.h"
+#include "../secp256k1/src/hash_impl.h"
+
+// This is the same as nonce_function_rfc6979 in secp256k1.c, without the
+// optional extra data and algorithm name.
+void nonce_rfc6979(unsigned char *nonce32, const unsigned char *msg32, const unsigned char *key32, unsigned int counter) {
+	unsigned char keydata[64];
+	secp256k1_rfc6979_hmac_sha256 rng;
+	unsigned int i;
+
+	memcpy(keydata, key32, 32);
+	memcpy(keydata + 32, msg32, 32);
+	secp256k1_rfc6979_hmac_sha256_initialize(&rng, keydata, 64);
+	memset(keydata, 0, sizeof(keydata));
+	for (i = 0; i <= counter; i++) {
+		secp256k1_rfc6979_hmac_sha256_generate(&rng, nonce32, 32);
+	}
+	secp256k1_rfc6979_hmac_sha256_finalize(&rng);
+}
+
+*/
+import "C"
+
+// nonceRFC6979 writes into the destination the deterministic nonce for the
+// given private key and message hash as defined in RFC 6979, where the counter
+// is the number of previously generated nonces that were rejected.
+func nonceRFC6979(dst *[32]byte, hash, key *[32]byte, counter uint) {
+	C.nonce_rfc6979((*C.uchar)(&dst[0]), (*C.uchar)(&hash[0]), (*C.uchar)(&key[0]), C.uint(counter))
+}
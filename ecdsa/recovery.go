@@ -0,0 +1,163 @@
+package ecdsa
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/surge"
+)
+
+// RecoverableSignatureSizeMarshalled is the number of bytes needed to
+// represent a marshalled recoverable signature.
+const RecoverableSignatureSizeMarshalled int = SignatureSizeMarshalled + 1
+
+var (
+	// The order of the elliptic curve group.
+	curveN, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+
+	// The prime that defines the field of the curve point coordinates.
+	curveP, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F", 16)
+)
+
+// RecoverableSignature represents an ECDSA signature together with a recovery
+// id, which allows the public key to be recovered from the signature and the
+// message hash. The recovery id is a number in the range [0, 3], where the
+// first bit is set if the y coordinate of the nonce point is odd, and the
+// second bit is set if the x coordinate of the nonce point was greater than or
+// equal to N.
+type RecoverableSignature struct {
+	Signature
+	RecoveryID byte
+}
+
+// SignRecoverable signs the given message hash with the given private key in
+// the same way as Sign, and additionally returns the recovery id of the
+// signature. An error is returned if the private key is zero.
+func SignRecoverable(priv *secp256k1.Fn, hash [32]byte) (RecoverableSignature, error) {
+	sig, recid, err := sign(priv, hash)
+	if err != nil {
+		return RecoverableSignature{}, err
+	}
+	return RecoverableSignature{Signature: sig, RecoveryID: recid}, nil
+}
+
+// RecoverPubKey returns the public key for which the given recoverable
+// signature is a valid signature of the given message hash. An error is
+// returned if no such public key exists. Signatures with a high S value are
+// accepted.
+func RecoverPubKey(hash [32]byte, sig *RecoverableSignature) (secp256k1.Point, error) {
+	if sig.RecoveryID > 3 {
+		return secp256k1.Point{}, fmt.Errorf("invalid recovery id: expected at most 3, got %v", sig.RecoveryID)
+	}
+	if sig.R.IsZero() || sig.S.IsZero() {
+		return secp256k1.Point{}, errors.New("invalid signature: zero value")
+	}
+
+	// Reconstruct the x coordinate of the nonce point, which is either r or
+	// r + N depending on the recovery id.
+	x := sig.R.Int()
+	if sig.RecoveryID&2 != 0 {
+		x.Add(x, curveN)
+		if x.Cmp(curveP) >= 0 {
+			return secp256k1.Point{}, errors.New("invalid signature: x coordinate out of range")
+		}
+	}
+
+	// Reconstruct the nonce point from the x coordinate and the parity of the
+	// y coordinate.
+	var bs [secp256k1.PointSizeMarshalled]byte
+	var R secp256k1.Point
+	bs[0] = sig.RecoveryID & 1
+	x.FillBytes(bs[1:])
+	if err := R.SetBytes(bs[:]); err != nil {
+		return secp256k1.Point{}, errors.New("invalid signature: x coordinate is not on the curve")
+	}
+
+	// pub = r^-1 * (s*R - m*G)
+	var m, rInv, u1, u2 secp256k1.Fn
+	m.SetB32(hash[:])
+	rInv.Inverse(&sig.R)
+	u1.Mul(&rInv, &m)
+	u1.Negate(&u1)
+	u2.Mul(&rInv, &sig.S)
+
+	var pub secp256k1.Point
+	pub.DoubleBaseExpVar(&u1, &R, &u2)
+	if pub.IsInfinity() {
+		return secp256k1.Point{}, errors.New("invalid signature: recovered point at infinity")
+	}
+
+	return pub, nil
+}
+
+// PutBytes stores the signature in the given byte slice in the 65 byte
+// compact form, which is the 64 byte compact form of the signature followed
+// by the recovery id.
+//
+// Panics: If the byte slice has length less than 65, this function will panic.
+func (sig *RecoverableSignature) PutBytes(dst []byte) {
+	if len(dst) < RecoverableSignatureSizeMarshalled {
+		panic(fmt.Sprintf("invalid slice length: length needs to be at least 65, got %v", len(dst)))
+	}
+
+	sig.Signature.PutBytes(dst[:SignatureSizeMarshalled])
+	dst[SignatureSizeMarshalled] = sig.RecoveryID
+}
+
+// SetBytes sets the signature from the given byte slice in the 65 byte compact
+// form. It will return an error if either R or S are greater than or equal to
+// N, or if the recovery id is greater than 3.
+//
+// Panics: If the byte slice has length less than 65, this function will panic.
+func (sig *RecoverableSignature) SetBytes(bs []byte) error {
+	if len(bs) < RecoverableSignatureSizeMarshalled {
+		panic(fmt.Sprintf("invalid slice length: length needs to be at least 65, got %v", len(bs)))
+	}
+
+	recid := bs[SignatureSizeMarshalled]
+	if recid > 3 {
+		*sig = RecoverableSignature{}
+		return fmt.Errorf("invalid recovery id: expected at most 3, got %v", recid)
+	}
+	if err := sig.Signature.SetBytes(bs[:SignatureSizeMarshalled]); err != nil {
+		*sig = RecoverableSignature{}
+		return err
+	}
+	sig.RecoveryID = recid
+	return nil
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (sig RecoverableSignature) SizeHint() int { return RecoverableSignatureSizeMarshalled }
+
+// Marshal implements the surge.Marshaler interface.
+func (sig RecoverableSignature) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := sig.Signature.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return surge.MarshalU8(sig.RecoveryID, buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface. As with SetBytes, an
+// error is returned if the recovery id is greater than 3.
+func (sig *RecoverableSignature) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := sig.Signature.Unmarshal(buf, rem)
+	if err != nil {
+		*sig = RecoverableSignature{}
+		return buf, rem, err
+	}
+	buf, rem, err = surge.UnmarshalU8(&sig.RecoveryID, buf, rem)
+	if err != nil {
+		*sig = RecoverableSignature{}
+		return buf, rem, err
+	}
+	if sig.RecoveryID > 3 {
+		recid := sig.RecoveryID
+		*sig = RecoverableSignature{}
+		return buf, rem, fmt.Errorf("invalid recovery id: expected at most 3, got %v", recid)
+	}
+	return buf, rem, nil
+}
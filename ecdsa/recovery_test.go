@@ -0,0 +1,243 @@
+package ecdsa_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"testing"
+
+	"github.com/dustinxie/ecc"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/renproject/secp256k1"
+	. "github.com/renproject/secp256k1/ecdsa"
+)
+
+var _ = Describe("Public key recovery", func() {
+	trials := 100
+
+	params := ecc.P256k1()
+
+	randomHash := func() [32]byte {
+		var hash [32]byte
+		if _, err := rand.Read(hash[:]); err != nil {
+			panic(err)
+		}
+		return hash
+	}
+
+	randomKeyPair := func() (secp256k1.Fn, secp256k1.Point) {
+		var pub secp256k1.Point
+		priv := secp256k1.RandomFn()
+		pub.BaseExp(&priv)
+		return priv, pub
+	}
+
+	It("should recover the public key of the signer", func() {
+		for i := 0; i < trials; i++ {
+			priv, pub := randomKeyPair()
+			hash := randomHash()
+
+			sig, err := SignRecoverable(&priv, hash)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sig.RecoveryID).To(BeNumerically("<=", 3))
+
+			recovered, err := RecoverPubKey(hash, &sig)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recovered.Eq(&pub)).To(BeTrue())
+		}
+	})
+
+	It("should produce the same signature as signing without recovery", func() {
+		for i := 0; i < trials; i++ {
+			priv, pub := randomKeyPair()
+			hash := randomHash()
+
+			sig, err := Sign(&priv, hash)
+			Expect(err).ToNot(HaveOccurred())
+			recSig, err := SignRecoverable(&priv, hash)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(recSig.R.Eq(&sig.R)).To(BeTrue())
+			Expect(recSig.S.Eq(&sig.S)).To(BeTrue())
+			Expect(Verify(&pub, hash, &recSig.Signature)).To(BeTrue())
+		}
+	})
+
+	It("should recover a different key for a different hash or recovery id", func() {
+		for i := 0; i < trials; i++ {
+			priv, pub := randomKeyPair()
+			hash := randomHash()
+
+			sig, err := SignRecoverable(&priv, hash)
+			Expect(err).ToNot(HaveOccurred())
+
+			recovered, err := RecoverPubKey(randomHash(), &sig)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recovered.Eq(&pub)).To(BeFalse())
+
+			sig.RecoveryID ^= 1
+			recovered, err = RecoverPubKey(hash, &sig)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recovered.Eq(&pub)).To(BeFalse())
+		}
+	})
+
+	It("should recover the same key for the high S form of the signature", func() {
+		for i := 0; i < trials; i++ {
+			priv, pub := randomKeyPair()
+			hash := randomHash()
+
+			sig, err := SignRecoverable(&priv, hash)
+			Expect(err).ToNot(HaveOccurred())
+
+			sig.S.Negate(&sig.S)
+			sig.RecoveryID ^= 1
+
+			recovered, err := RecoverPubKey(hash, &sig)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recovered.Eq(&pub)).To(BeTrue())
+		}
+	})
+
+	It("should return an error for invalid signatures", func() {
+		priv, _ := randomKeyPair()
+		hash := randomHash()
+		sig, err := SignRecoverable(&priv, hash)
+		Expect(err).ToNot(HaveOccurred())
+
+		modified := sig
+		modified.RecoveryID = 4
+		_, err = RecoverPubKey(hash, &modified)
+		Expect(err).To(HaveOccurred())
+
+		modified = sig
+		modified.R.Clear()
+		_, err = RecoverPubKey(hash, &modified)
+		Expect(err).To(HaveOccurred())
+
+		modified = sig
+		modified.S.Clear()
+		_, err = RecoverPubKey(hash, &modified)
+		Expect(err).To(HaveOccurred())
+
+		// For a random r, r + N is greater than P except with negligible
+		// probability.
+		modified = sig
+		modified.RecoveryID |= 2
+		_, err = RecoverPubKey(hash, &modified)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should interoperate with another implementation", func() {
+		var bs [RecoverableSignatureSizeMarshalled]byte
+		for i := 0; i < trials; i++ {
+			priv, pub := randomKeyPair()
+			hash := randomHash()
+
+			// Signatures produced by this implementation.
+			sig, err := SignRecoverable(&priv, hash)
+			Expect(err).ToNot(HaveOccurred())
+			sig.PutBytes(bs[:])
+
+			stdPub, err := ecc.RecoverPubkey("P-256k1", hash[:], bs[:])
+			Expect(err).ToNot(HaveOccurred())
+			x, y, err := pub.XY()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stdPub.X.Cmp(x.Int())).To(Equal(0))
+			Expect(stdPub.Y.Cmp(y.Int())).To(Equal(0))
+
+			// Signatures produced by the other implementation.
+			stdPriv := &ecdsa.PrivateKey{PublicKey: *stdPub, D: priv.Int()}
+			stdPriv.Curve = params
+			stdSig, err := ecc.SignBytes(stdPriv, hash[:], ecc.LowerS|ecc.RecID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sig.SetBytes(stdSig)).To(Succeed())
+
+			recovered, err := RecoverPubKey(hash, &sig)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recovered.Eq(&pub)).To(BeTrue())
+		}
+	})
+
+	Context("when marshalling", func() {
+		It("should be equal after converting to and from bytes", func() {
+			var bs [RecoverableSignatureSizeMarshalled]byte
+			var after RecoverableSignature
+			for i := 0; i < trials; i++ {
+				priv, _ := randomKeyPair()
+				before, err := SignRecoverable(&priv, randomHash())
+				Expect(err).ToNot(HaveOccurred())
+
+				before.PutBytes(bs[:])
+				Expect(after.SetBytes(bs[:])).To(Succeed())
+				Expect(after.R.Eq(&before.R)).To(BeTrue())
+				Expect(after.S.Eq(&before.S)).To(BeTrue())
+				Expect(after.RecoveryID).To(Equal(before.RecoveryID))
+			}
+		})
+
+		It("should return an error when the recovery id is out of range", func() {
+			var bs [RecoverableSignatureSizeMarshalled]byte
+			var sig RecoverableSignature
+			priv, _ := randomKeyPair()
+			before, err := SignRecoverable(&priv, randomHash())
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, recid := range []byte{4, 200, 255} {
+				before.PutBytes(bs[:])
+				bs[RecoverableSignatureSizeMarshalled-1] = recid
+				Expect(sig.SetBytes(bs[:])).ToNot(Succeed())
+
+				_, _, err = sig.Unmarshal(bs[:], 2*secp256k1.FnSize+1)
+				Expect(err).To(HaveOccurred())
+				Expect(sig.R.IsZero()).To(BeTrue())
+				Expect(sig.S.IsZero()).To(BeTrue())
+				Expect(sig.RecoveryID).To(Equal(byte(0)))
+			}
+		})
+
+		It("should be equal after marshaling and unmarshaling with surge", func() {
+			var bs [RecoverableSignatureSizeMarshalled]byte
+			var after RecoverableSignature
+			for i := 0; i < trials; i++ {
+				priv, _ := randomKeyPair()
+				before, err := SignRecoverable(&priv, randomHash())
+				Expect(err).ToNot(HaveOccurred())
+
+				tail, rem, err := before.Marshal(bs[:], before.SizeHint())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rem).To(Equal(0))
+				Expect(len(tail)).To(Equal(0))
+
+				tail, rem, err = after.Unmarshal(bs[:], 2*secp256k1.FnSize+1)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rem).To(Equal(0))
+				Expect(len(tail)).To(Equal(0))
+
+				Expect(after.R.Eq(&before.R)).To(BeTrue())
+				Expect(after.S.Eq(&before.S)).To(BeTrue())
+				Expect(after.RecoveryID).To(Equal(before.RecoveryID))
+			}
+		})
+
+		It("should panic when the slice length is too small", func() {
+			var sig RecoverableSignature
+			var bs [RecoverableSignatureSizeMarshalled - 1]byte
+			for i := 0; i < RecoverableSignatureSizeMarshalled-1; i++ {
+				Expect(func() { sig.PutBytes(bs[:i]) }).To(Panic())
+				Expect(func() { sig.SetBytes(bs[:i]) }).To(Panic())
+			}
+		})
+	})
+})
+
+func BenchmarkRecoverPubKey(b *testing.B) {
+	priv := secp256k1.RandomFn()
+	hash := [32]byte{}
+	sig, _ := SignRecoverable(&priv, hash)
+
+	for i := 0; i < b.N; i++ {
+		_, _ = RecoverPubKey(hash, &sig)
+	}
+}
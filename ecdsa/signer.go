@@ -0,0 +1,113 @@
+package ecdsa
+
+import (
+	"crypto"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/renproject/secp256k1"
+)
+
+// Ensure that PrivateKey implements crypto.Signer.
+var _ crypto.Signer = (*PrivateKey)(nil)
+
+// asn1Signature is the ASN.1 structure of an ECDSA signature as defined in
+// RFC 3279.
+type asn1Signature struct {
+	R, S *big.Int
+}
+
+// Public implements the crypto.Signer interface. The returned value has type
+// *PublicKey, and can be used with VerifyASN1.
+func (priv *PrivateKey) Public() crypto.PublicKey {
+	pub := priv.PublicKey()
+	return &pub
+}
+
+// Sign implements the crypto.Signer interface. It signs the given digest and
+// returns the signature in ASN.1 DER form. As with Sign, the nonce is derived
+// deterministically using RFC 6979, so the given source of randomness is not
+// used. If opts specifies a hash function, the digest must have the size of
+// that hash function. Digests longer than 32 bytes are truncated to their
+// first 32 bytes, as specified for ECDSA.
+func (priv *PrivateKey) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts != nil && opts.HashFunc() != 0 && len(digest) != opts.HashFunc().Size() {
+		return nil, fmt.Errorf("invalid digest length: expected %v bytes, got %v", opts.HashFunc().Size(), len(digest))
+	}
+
+	sig, err := Sign(&priv.scalar, hashFromDigest(digest))
+	if err != nil {
+		return nil, err
+	}
+	return sig.MarshalASN1()
+}
+
+// VerifyASN1 returns true if the given ASN.1 DER encoded signature is a valid
+// signature of the given digest for the given public key, and false otherwise.
+// The digest is treated in the same way as by PrivateKey.Sign. Unlike Verify,
+// signatures with a high S value are accepted, since other implementations
+// that produce ASN.1 signatures do not normalise them.
+func VerifyASN1(pub *PublicKey, digest []byte, sig []byte) bool {
+	var s Signature
+	if err := s.UnmarshalASN1(sig); err != nil {
+		return false
+	}
+	s.Normalize()
+	return Verify(&pub.point, hashFromDigest(digest), &s)
+}
+
+// MarshalASN1 returns the ASN.1 DER encoding of the signature, which is a
+// sequence of the two integers R and S.
+func (sig *Signature) MarshalASN1() ([]byte, error) {
+	return asn1.Marshal(asn1Signature{R: sig.R.Int(), S: sig.S.Int()})
+}
+
+// UnmarshalASN1 sets the signature from the given ASN.1 DER encoding. It will
+// return an error if the encoding is malformed or has trailing data, or if
+// either R or S are not in the range [1, N - 1]. If an error is returned, the
+// signature is left unchanged.
+func (sig *Signature) UnmarshalASN1(der []byte) error {
+	var s asn1Signature
+	rest, err := asn1.Unmarshal(der, &s)
+	if err != nil {
+		return fmt.Errorf("invalid signature data: %v", err)
+	}
+	if len(rest) != 0 {
+		return errors.New("invalid signature data: trailing data")
+	}
+
+	var r, sv secp256k1.Fn
+	if !setScalar(&r, s.R) || !setScalar(&sv, s.S) {
+		return errors.New("invalid signature data: value out of range")
+	}
+
+	sig.R, sig.S = r, sv
+	return nil
+}
+
+// setScalar sets the given scalar to the given integer and returns true if it
+// is in the range [1, N - 1], and otherwise returns false.
+func setScalar(dst *secp256k1.Fn, x *big.Int) bool {
+	if x.Sign() <= 0 || x.BitLen() > 256 {
+		return false
+	}
+	var bs [32]byte
+	x.FillBytes(bs[:])
+	return dst.SetB32Canonical(bs[:]) == nil
+}
+
+// hashFromDigest converts the given digest to the 32 byte hash that is signed.
+// Longer digests are truncated to their leftmost 256 bits, and shorter digests
+// are interpreted as big endian integers.
+func hashFromDigest(digest []byte) [32]byte {
+	var hash [32]byte
+	if len(digest) >= 32 {
+		copy(hash[:], digest[:32])
+	} else {
+		copy(hash[32-len(digest):], digest)
+	}
+	return hash
+}
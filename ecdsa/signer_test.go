@@ -0,0 +1,239 @@
+package ecdsa_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/renproject/secp256k1"
+	. "github.com/renproject/secp256k1/ecdsa"
+)
+
+var _ = Describe("Signer", func() {
+	trials := 100
+
+	curveN := secp256k1.Curve().Params().N
+
+	randomDigest := func() []byte {
+		digest := make([]byte, 32)
+		if _, err := rand.Read(digest); err != nil {
+			panic(err)
+		}
+		return digest
+	}
+
+	randomPrivateKey := func() *PrivateKey {
+		priv, err := GeneratePrivateKey()
+		if err != nil {
+			panic(err)
+		}
+		return &priv
+	}
+
+	stdPublicKey := func(pub *PublicKey) *ecdsa.PublicKey {
+		point := pub.Point()
+		x, y, err := point.XY()
+		if err != nil {
+			panic(err)
+		}
+		return &ecdsa.PublicKey{Curve: secp256k1.Curve(), X: x.Int(), Y: y.Int()}
+	}
+
+	stdPrivateKey := func(priv *PrivateKey) *ecdsa.PrivateKey {
+		pub := priv.PublicKey()
+		scalar := priv.Scalar()
+		return &ecdsa.PrivateKey{PublicKey: *stdPublicKey(&pub), D: scalar.Int()}
+	}
+
+	Context("when signing", func() {
+		It("should implement crypto.Signer", func() {
+			var signer crypto.Signer = randomPrivateKey()
+			_, ok := signer.Public().(*PublicKey)
+			Expect(ok).To(BeTrue())
+		})
+
+		It("should return the public key of the private key", func() {
+			for i := 0; i < trials; i++ {
+				priv := randomPrivateKey()
+				pub := priv.PublicKey()
+				Expect(priv.Public().(*PublicKey).Eq(&pub)).To(BeTrue())
+			}
+		})
+
+		It("should produce signatures accepted by VerifyASN1", func() {
+			for i := 0; i < trials; i++ {
+				priv := randomPrivateKey()
+				digest := randomDigest()
+				sig, err := priv.Sign(rand.Reader, digest, crypto.SHA256)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(VerifyASN1(priv.Public().(*PublicKey), digest, sig)).To(BeTrue())
+			}
+		})
+
+		It("should produce the same signatures as Sign", func() {
+			for i := 0; i < trials; i++ {
+				priv := randomPrivateKey()
+				var hash [32]byte
+				copy(hash[:], randomDigest())
+				der, err := priv.Sign(nil, hash[:], crypto.SHA256)
+				Expect(err).ToNot(HaveOccurred())
+
+				scalar := priv.Scalar()
+				expected, err := Sign(&scalar, hash)
+				Expect(err).ToNot(HaveOccurred())
+
+				var sig Signature
+				Expect(sig.UnmarshalASN1(der)).To(Succeed())
+				Expect(sig.R.Eq(&expected.R)).To(BeTrue())
+				Expect(sig.S.Eq(&expected.S)).To(BeTrue())
+			}
+		})
+
+		It("should produce signatures accepted by another implementation", func() {
+			for i := 0; i < trials; i++ {
+				priv := randomPrivateKey()
+				digest := randomDigest()
+				sig, err := priv.Sign(rand.Reader, digest, crypto.SHA256)
+				Expect(err).ToNot(HaveOccurred())
+				pub := priv.PublicKey()
+				Expect(ecdsa.VerifyASN1(stdPublicKey(&pub), digest, sig)).To(BeTrue())
+			}
+		})
+
+		It("should truncate digests that are longer than 32 bytes", func() {
+			for i := 0; i < trials; i++ {
+				priv := randomPrivateKey()
+				digest := sha512.Sum512(randomDigest())
+				sig, err := priv.Sign(rand.Reader, digest[:], crypto.SHA512)
+				Expect(err).ToNot(HaveOccurred())
+				pub := priv.PublicKey()
+				Expect(ecdsa.VerifyASN1(stdPublicKey(&pub), digest[:], sig)).To(BeTrue())
+				Expect(VerifyASN1(&pub, digest[:], sig)).To(BeTrue())
+			}
+		})
+
+		It("should return an error if the digest does not match the hash function", func() {
+			priv := randomPrivateKey()
+			_, err := priv.Sign(rand.Reader, make([]byte, 31), crypto.SHA256)
+			Expect(err).To(HaveOccurred())
+			_, err = priv.Sign(rand.Reader, make([]byte, 32), crypto.SHA512)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when verifying", func() {
+		It("should accept signatures from another implementation", func() {
+			for i := 0; i < trials; i++ {
+				priv := randomPrivateKey()
+				digest := sha256.Sum256(randomDigest())
+				sig, err := ecdsa.SignASN1(rand.Reader, stdPrivateKey(priv), digest[:])
+				Expect(err).ToNot(HaveOccurred())
+				pub := priv.PublicKey()
+				Expect(VerifyASN1(&pub, digest[:], sig)).To(BeTrue())
+			}
+		})
+
+		It("should accept signatures with a high S value", func() {
+			for i := 0; i < trials; i++ {
+				priv := randomPrivateKey()
+				digest := randomDigest()
+				der, err := priv.Sign(rand.Reader, digest, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				var sig struct{ R, S *big.Int }
+				_, err = asn1.Unmarshal(der, &sig)
+				Expect(err).ToNot(HaveOccurred())
+				sig.S.Sub(curveN, sig.S)
+				der, err = asn1.Marshal(sig)
+				Expect(err).ToNot(HaveOccurred())
+
+				pub := priv.PublicKey()
+				Expect(VerifyASN1(&pub, digest, der)).To(BeTrue())
+			}
+		})
+
+		It("should reject signatures for a different digest or key", func() {
+			for i := 0; i < trials; i++ {
+				priv := randomPrivateKey()
+				digest := randomDigest()
+				sig, err := priv.Sign(rand.Reader, digest, crypto.SHA256)
+				Expect(err).ToNot(HaveOccurred())
+
+				pub := priv.PublicKey()
+				other := randomPrivateKey().PublicKey()
+				Expect(VerifyASN1(&pub, randomDigest(), sig)).To(BeFalse())
+				Expect(VerifyASN1(&other, digest, sig)).To(BeFalse())
+			}
+		})
+
+		It("should reject malformed signatures", func() {
+			priv := randomPrivateKey()
+			pub := priv.PublicKey()
+			digest := randomDigest()
+			sig, err := priv.Sign(rand.Reader, digest, crypto.SHA256)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(VerifyASN1(&pub, digest, nil)).To(BeFalse())
+			Expect(VerifyASN1(&pub, digest, sig[:len(sig)-1])).To(BeFalse())
+			Expect(VerifyASN1(&pub, digest, append(sig, 0))).To(BeFalse())
+		})
+	})
+
+	Context("when encoding signatures", func() {
+		It("should be equal after marshaling and unmarshaling", func() {
+			for i := 0; i < trials; i++ {
+				priv := randomPrivateKey()
+				scalar := priv.Scalar()
+				var hash [32]byte
+				copy(hash[:], randomDigest())
+				sig, err := Sign(&scalar, hash)
+				Expect(err).ToNot(HaveOccurred())
+
+				der, err := sig.MarshalASN1()
+				Expect(err).ToNot(HaveOccurred())
+				var decoded Signature
+				Expect(decoded.UnmarshalASN1(der)).To(Succeed())
+				Expect(decoded.R.Eq(&sig.R)).To(BeTrue())
+				Expect(decoded.S.Eq(&sig.S)).To(BeTrue())
+			}
+		})
+
+		It("should return an error when unmarshaling values that are out of range", func() {
+			one := big.NewInt(1)
+			values := []struct{ R, S *big.Int }{
+				{big.NewInt(0), one},
+				{one, big.NewInt(0)},
+				{big.NewInt(-1), one},
+				{one, big.NewInt(-1)},
+				{curveN, one},
+				{one, curveN},
+				{new(big.Int).Lsh(one, 256), one},
+			}
+			for _, value := range values {
+				der, err := asn1.Marshal(value)
+				Expect(err).ToNot(HaveOccurred())
+				var sig Signature
+				Expect(sig.UnmarshalASN1(der)).ToNot(Succeed())
+				Expect(sig.R.IsZero()).To(BeTrue())
+				Expect(sig.S.IsZero()).To(BeTrue())
+			}
+		})
+	})
+})
+
+func BenchmarkSignerSign(b *testing.B) {
+	priv, _ := GeneratePrivateKey()
+	signer := &priv
+	digest := make([]byte, 32)
+
+	for i := 0; i < b.N; i++ {
+		_, _ = signer.Sign(nil, digest, crypto.SHA256)
+	}
+}
@@ -0,0 +1,348 @@
+package secp256k1
+
+/*
+
+#define USE_NUM_GMP
+#define HAVE___INT128
+
+// The ecmult context stores a table of (1 << (ECMULT_WINDOW_SIZE - 2)) odd
+// multiples of the generator, each taking 64 bytes. A window size of 15 is the
+// default used by libsecp256k1 and results in a 512KB table.
+#define ECMULT_WINDOW_SIZE 15
+
+// The ecmult gen context stores (256 / ECMULT_GEN_PREC_BITS) *
+// (1 << ECMULT_GEN_PREC_BITS) precomputed points, each taking 64 bytes. A
+// value of 4 is the default used by libsecp256k1 and results in a 64KB table.
+#define ECMULT_GEN_PREC_BITS 4
+
+#include "secp256k1/include/secp256k1.h"
+
+#define USE_SCALAR_4X64
+#define USE_SCALAR_INV_BUILTIN
+
+#include "secp256k1/src/util.h"
+#include "secp256k1/src/num_gmp_impl.h"
+#include "secp256k1/src/scalar.h"
+#include "secp256k1/src/scalar_impl.h"
+#include "secp256k1/src/scalar_4x64_impl.h"
+
+#define USE_ASM_X86_64
+#define USE_FIELD_5X52
+#define USE_FIELD_INV_BUILTIN
+
+#include "secp256k1/src/field.h"
+#include "secp256k1/src/field_impl.h"
+#include "secp256k1/src/field_5x52_impl.h"
+#include "secp256k1/src/num_impl.h"
+
+#include "secp256k1/src/group.h"
+#include "secp256k1/src/group_impl.h"
+#include "secp256k1/src/scratch.h"
+#include "secp256k1/src/scratch_impl.h"
+#include "secp256k1/src/ecmult.h"
+#include "secp256k1/src/ecmult_impl.h"
+#include "secp256k1/src/ecmult_const.h"
+#include "secp256k1/src/ecmult_const_impl.h"
+#include "secp256k1/src/ecmult_gen.h"
+#include "secp256k1/src/ecmult_gen_impl.h"
+
+// The maximum number of points that the scratch space for a single multi
+// scalar multiplication is sized for. Larger inputs are split into batches by
+// secp256k1_ecmult_multi_var.
+#define MULTI_SCALE_MAX_BATCH_POINTS 16384
+
+static void abort_callback_fn(const char* str, void* data) {
+	(void)data;
+	fprintf(stderr, "[libsecp256k1] internal consistency check failed: %s\n", str);
+	abort();
+}
+
+static const secp256k1_callback abort_callback = {
+	abort_callback_fn,
+	NULL
+};
+
+// NOTE: cgo cannot reference static variables, so the context is only
+// accessed through the functions below.
+static secp256k1_ecmult_context ecmult_ctx;
+
+void build_ecmult_context(void) {
+	void *prealloc = checked_malloc(&abort_callback, SECP256K1_ECMULT_CONTEXT_PREALLOCATED_SIZE);
+	secp256k1_ecmult_context_init(&ecmult_ctx);
+	secp256k1_ecmult_context_build(&ecmult_ctx, &prealloc);
+}
+
+void double_base_exp_var(secp256k1_gej *r, const secp256k1_scalar *ng, const secp256k1_gej *a, const secp256k1_scalar *na) {
+	secp256k1_gej tmp;
+	secp256k1_ecmult(&ecmult_ctx, &tmp, a, na, ng);
+	*r = tmp;
+}
+
+static secp256k1_ecmult_gen_context ecmult_gen_ctx;
+
+void build_ecmult_gen_context(void) {
+	void *prealloc = checked_malloc(&abort_callback, SECP256K1_ECMULT_GEN_CONTEXT_PREALLOCATED_SIZE);
+	secp256k1_ecmult_gen_context_init(&ecmult_gen_ctx);
+	secp256k1_ecmult_gen_context_build(&ecmult_gen_ctx, &prealloc);
+}
+
+void ecmult_gen(secp256k1_gej *r, const secp256k1_scalar *a) {
+	secp256k1_ecmult_gen(&ecmult_gen_ctx, r, a);
+}
+
+void ecmult_gen_blind(const unsigned char *seed32) {
+	secp256k1_ecmult_gen_blind(&ecmult_gen_ctx, seed32);
+}
+
+typedef struct {
+	const secp256k1_scalar *scalars;
+	const secp256k1_ge *points;
+} multi_scale_data;
+
+static int multi_scale_callback(secp256k1_scalar *sc, secp256k1_ge *pt, size_t idx, void *data) {
+	multi_scale_data *d = (multi_scale_data *)data;
+	*sc = d->scalars[idx];
+	*pt = d->points[idx];
+	return 1;
+}
+
+static size_t multi_scale_scratch_size(size_t n) {
+	size_t size;
+	if (n > MULTI_SCALE_MAX_BATCH_POINTS) {
+		n = MULTI_SCALE_MAX_BATCH_POINTS;
+	}
+	if (n >= ECMULT_PIPPENGER_THRESHOLD) {
+		size = secp256k1_pippenger_scratch_size(n, secp256k1_pippenger_bucket_window(n));
+	} else {
+		size = secp256k1_strauss_scratch_size(n);
+	}
+
+	// Each object allocated on the scratch space can lose up to ALIGNMENT - 1
+	// bytes to padding.
+	return size + PIPPENGER_SCRATCH_OBJECTS * ALIGNMENT;
+}
+
+int multi_scale_var(secp256k1_gej *r, const secp256k1_gej *points, const secp256k1_scalar *scalars, size_t n) {
+	multi_scale_data data;
+	secp256k1_scratch *scratch;
+	secp256k1_ge *ge;
+	size_t i;
+	int ret;
+
+	// secp256k1_ge_set_all_gej_var returns without writing to its output if
+	// all of the points are at infinity, so the infinity flags are set here.
+	ge = (secp256k1_ge *)checked_malloc(&abort_callback, n * sizeof(secp256k1_ge));
+	memset(ge, 0, n * sizeof(secp256k1_ge));
+	for (i = 0; i < n; i++) {
+		ge[i].infinity = 1;
+	}
+	secp256k1_ge_set_all_gej_var(ge, points, n);
+	data.scalars = scalars;
+	data.points = ge;
+
+	scratch = secp256k1_scratch_create(&abort_callback, multi_scale_scratch_size(n));
+	ret = secp256k1_ecmult_multi_var(&abort_callback, &ecmult_ctx, scratch, r, NULL, multi_scale_callback, &data, n);
+	secp256k1_scratch_destroy(&abort_callback, scratch);
+	free(ge);
+
+	return ret;
+}
+
+void multi_scale(secp256k1_gej *r, const secp256k1_gej *points, const secp256k1_scalar *scalars, size_t n) {
+	secp256k1_gej acc, tmp;
+	secp256k1_ge ge;
+	size_t i;
+
+	// The result is accumulated in a temporary so that the receiver can alias
+	// one of the input points.
+	secp256k1_gej_set_infinity(&acc);
+	for (i = 0; i < n; i++) {
+		if (secp256k1_gej_is_infinity(&points[i])) {
+			continue;
+		}
+		// secp256k1_ge_set_gej modifies its argument, so we pass it a copy.
+		tmp = points[i];
+		secp256k1_ge_set_gej(&ge, &tmp);
+		// The final argument should be the maximum bit length of the absolute
+		// value of the scalar plus one, hence 256 + 1.
+		secp256k1_ecmult_const(&tmp, &ge, &scalars[i], 257);
+		secp256k1_gej_add_var(&acc, &acc, &tmp, NULL);
+	}
+	*r = acc;
+}
+
+*/
+import "C"
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"sync"
+	"unsafe"
+)
+
+var ecmultContextOnce sync.Once
+
+// ensureEcmultContext builds the precomputed tables used for variable time
+// scalar multiplication if they have not been built already.
+func ensureEcmultContext() {
+	ecmultContextOnce.Do(func() { C.build_ecmult_context() })
+}
+
+var (
+	ecmultGenContextOnce sync.Once
+
+	// The blinding values in the ecmult gen context are read by every base
+	// exponentiation and written when reseeding, so access to the context is
+	// guarded by this lock.
+	ecmultGenContextMu sync.RWMutex
+)
+
+// ensureEcmultGenContext builds the precomputed tables used for constant time
+// base exponentiation if they have not been built already. When the tables
+// are first built, the blinding is seeded using crypto/rand.
+func ensureEcmultGenContext() {
+	ecmultGenContextOnce.Do(func() {
+		C.build_ecmult_gen_context()
+
+		// If reading from the random source fails, the context will still use
+		// the default blinding values, which is correct but gives no
+		// protection against side channels until ReseedBaseExpBlinding is
+		// called.
+		var seed [32]byte
+		if _, err := io.ReadFull(rand.Reader, seed[:]); err == nil {
+			C.ecmult_gen_blind((*C.uchar)(&seed[0]))
+		}
+	})
+}
+
+// ecmultGen computes the scalar multiplication of the canonical generator of
+// the curve by the given scalar using the precomputed tables.
+func ecmultGen(dst *C.secp256k1_gej, scalar *C.secp256k1_scalar) {
+	ensureEcmultGenContext()
+
+	ecmultGenContextMu.RLock()
+	C.ecmult_gen(dst, scalar)
+	ecmultGenContextMu.RUnlock()
+
+	// The multiplication function doesn't make sure that the coordinates are
+	// normalized, so we need to do this manually.
+	normalizeXYZ(dst)
+}
+
+// ReseedBaseExpBlinding updates the blinding values that are used to protect
+// base exponentiation against side channel attacks. The new values are derived
+// from the given 32 byte seed and the previous blinding values, so the seed
+// should be kept secret and ideally be freshly generated randomness. If the
+// seed is nil, the blinding is instead reset to the initial state. This is
+// safe to call concurrently with base exponentiation.
+//
+// Panics: If the seed is not nil and has length less than 32, this function
+// will panic.
+func ReseedBaseExpBlinding(seed []byte) {
+	if seed != nil && len(seed) < 32 {
+		panic(fmt.Sprintf("invalid slice length: length needs to be at least 32, got %v", len(seed)))
+	}
+
+	ensureEcmultGenContext()
+
+	ecmultGenContextMu.Lock()
+	defer ecmultGenContextMu.Unlock()
+
+	if seed == nil {
+		C.ecmult_gen_blind(nil)
+		return
+	}
+	C.ecmult_gen_blind((*C.uchar)(&seed[0]))
+}
+
+// MultiScale computes the sum of the scalar multiplications of the given curve
+// points by the corresponding scalars, that is scalars[0]*points[0] + ... +
+// scalars[n-1]*points[n-1], and stores the result in the receiver. Each term
+// is computed in constant time, but the computation is done in a single call
+// to the c library. Points at infinity are allowed, and if the slices are
+// empty the result is the point at infinity.
+//
+// Panics: If the slices have different lengths, this function will panic.
+func (p *Point) MultiScale(points []Point, scalars []Fn) {
+	if len(points) != len(scalars) {
+		panic(fmt.Sprintf("mismatched slice lengths: %v points and %v scalars", len(points), len(scalars)))
+	}
+	if len(points) == 0 {
+		*p = NewPointInfinity()
+		return
+	}
+
+	C.multi_scale(
+		&p.inner,
+		(*C.secp256k1_gej)(unsafe.Pointer(&points[0])),
+		(*C.secp256k1_scalar)(unsafe.Pointer(&scalars[0])),
+		C.size_t(len(points)),
+	)
+	normalizeXYZ(&p.inner)
+}
+
+// MultiScaleVar is the same as MultiScale, but uses the variable time Strauss
+// or Pippenger algorithm (depending on the number of points) to compute the
+// sum. This is significantly faster than MultiScale for more than a few
+// points, but it must only be used when the points and scalars are public.
+//
+// Panics: If the slices have different lengths, this function will panic.
+func (p *Point) MultiScaleVar(points []Point, scalars []Fn) {
+	if len(points) != len(scalars) {
+		panic(fmt.Sprintf("mismatched slice lengths: %v points and %v scalars", len(points), len(scalars)))
+	}
+	if len(points) == 0 {
+		*p = NewPointInfinity()
+		return
+	}
+
+	ensureEcmultContext()
+
+	// The c function only fails when the callback fails, which ours never
+	// does, so we ignore the return value.
+	_ = C.multi_scale_var(
+		&p.inner,
+		(*C.secp256k1_gej)(unsafe.Pointer(&points[0])),
+		(*C.secp256k1_scalar)(unsafe.Pointer(&scalars[0])),
+		C.size_t(len(points)),
+	)
+	normalizeXYZ(&p.inner)
+}
+
+// DoubleBaseExpVar computes a*G + b*P, where G is the canonical generator of
+// the curve, and stores the result in the receiver. The given point can be the
+// point at infinity. This is several times faster than computing the two
+// scalar multiplications separately, but it is not constant time and so must
+// only be used with public data, for example when verifying signatures.
+func (p *Point) DoubleBaseExpVar(a *Fn, P *Point, b *Fn) {
+	if a == nil {
+		panic("expected first argument to not be nil")
+	}
+	if P == nil {
+		panic("expected second argument to not be nil")
+	}
+	if b == nil {
+		panic("expected third argument to not be nil")
+	}
+
+	p.DoubleBaseExpVarUnsafe(a, P, b)
+}
+
+// DoubleBaseExpVarUnsafe computes a*G + b*P, where G is the canonical
+// generator of the curve, and stores the result in the receiver. The given
+// point can be the point at infinity. This is not constant time and so must
+// only be used with public data.
+//
+// Unsafe: If this function receives nil arguments, the behaviour is
+// implementation dependent, because the definition of the NULL pointer in c is
+// implementation dependent.
+func (p *Point) DoubleBaseExpVarUnsafe(a *Fn, P *Point, b *Fn) {
+	ensureEcmultContext()
+
+	C.double_base_exp_var(&p.inner, &a.inner, &P.inner, &b.inner)
+
+	// The multiplication function doesn't make sure that the coordinates are
+	// normalized, so we need to do this manually.
+	normalizeXYZ(&p.inner)
+}
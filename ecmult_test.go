@@ -0,0 +1,359 @@
+package secp256k1_test
+
+import (
+	"crypto/rand"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/renproject/secp256k1"
+)
+
+var _ = Describe("Multi scalar multiplication", func() {
+	trials := 100
+
+	inf := NewPointInfinity()
+
+	randomPointsAndScalars := func(n int) ([]Point, []Fn) {
+		points := make([]Point, n)
+		scalars := make([]Fn, n)
+		for i := range points {
+			points[i] = RandomPoint()
+			scalars[i] = RandomFn()
+		}
+		return points, scalars
+	}
+
+	naiveMultiScale := func(points []Point, scalars []Fn) Point {
+		var term Point
+		acc := NewPointInfinity()
+		for i := range points {
+			term.ScaleExt(&points[i], &scalars[i])
+			acc.Add(&acc, &term)
+		}
+		return acc
+	}
+
+	// Sizes on either side of the threshold at which Pippenger's algorithm is
+	// used instead of Strauss' algorithm.
+	sizes := []int{1, 2, 3, 10, 159, 160, 161, 300}
+
+	It("should compute the same result as scaling and adding", func() {
+		var actual Point
+		for _, n := range sizes {
+			points, scalars := randomPointsAndScalars(n)
+			expected := naiveMultiScale(points, scalars)
+
+			actual.MultiScale(points, scalars)
+			Expect(actual.Eq(&expected)).To(BeTrue())
+		}
+	})
+
+	It("should compute the same result as scaling and adding (variable time)", func() {
+		var actual Point
+		for _, n := range sizes {
+			points, scalars := randomPointsAndScalars(n)
+			expected := naiveMultiScale(points, scalars)
+
+			actual.MultiScaleVar(points, scalars)
+			Expect(actual.Eq(&expected)).To(BeTrue())
+		}
+	})
+
+	It("should agree with the constant time variant", func() {
+		var ct, vt Point
+		for i := 0; i < trials; i++ {
+			points, scalars := randomPointsAndScalars(i%20 + 1)
+
+			ct.MultiScale(points, scalars)
+			vt.MultiScaleVar(points, scalars)
+			Expect(ct.Eq(&vt)).To(BeTrue())
+		}
+	})
+
+	It("should return the point at infinity for empty input", func() {
+		p := RandomPoint()
+		p.MultiScale(nil, nil)
+		Expect(p.IsInfinity()).To(BeTrue())
+
+		p = RandomPoint()
+		p.MultiScaleVar(nil, nil)
+		Expect(p.IsInfinity()).To(BeTrue())
+	})
+
+	It("should ignore points at infinity and zero scalars", func() {
+		var actual, expected Point
+		for i := 0; i < trials; i++ {
+			points, scalars := randomPointsAndScalars(4)
+			points[1] = inf
+			scalars[2].Clear()
+			expected = naiveMultiScale([]Point{points[0], points[3]}, []Fn{scalars[0], scalars[3]})
+
+			actual.MultiScale(points, scalars)
+			Expect(actual.Eq(&expected)).To(BeTrue())
+
+			actual.MultiScaleVar(points, scalars)
+			Expect(actual.Eq(&expected)).To(BeTrue())
+		}
+	})
+
+	It("should return the point at infinity when all of the points are at infinity", func() {
+		var p Point
+		for i := 0; i < trials; i++ {
+			points, scalars := randomPointsAndScalars(i%20 + 1)
+			for j := range points {
+				points[j] = inf
+			}
+
+			p = RandomPoint()
+			p.MultiScale(points, scalars)
+			Expect(p.IsInfinity()).To(BeTrue())
+
+			p = RandomPoint()
+			p.MultiScaleVar(points, scalars)
+			Expect(p.IsInfinity()).To(BeTrue())
+		}
+	})
+
+	It("should compute correctly when only some of the points are at infinity", func() {
+		var actual, expected Point
+		for i := 0; i < trials; i++ {
+			n := i%20 + 2
+			points, scalars := randomPointsAndScalars(n)
+			for j := range points {
+				if (i>>uint(j%8))&1 == 1 {
+					points[j] = inf
+				}
+			}
+			expected = naiveMultiScale(points, scalars)
+
+			actual.MultiScale(points, scalars)
+			Expect(actual.Eq(&expected)).To(BeTrue())
+
+			actual.MultiScaleVar(points, scalars)
+			Expect(actual.Eq(&expected)).To(BeTrue())
+		}
+	})
+
+	It("should return the point at infinity when the terms cancel", func() {
+		var p Point
+		for i := 0; i < trials; i++ {
+			points, scalars := randomPointsAndScalars(2)
+			points[1] = points[0]
+			scalars[1].Negate(&scalars[0])
+
+			p.MultiScale(points, scalars)
+			Expect(p.IsInfinity()).To(BeTrue())
+
+			p.MultiScaleVar(points, scalars)
+			Expect(p.IsInfinity()).To(BeTrue())
+		}
+	})
+
+	It("should compute correctly when the receiver is an alias of an input point", func() {
+		for i := 0; i < trials; i++ {
+			points, scalars := randomPointsAndScalars(3)
+			expected := naiveMultiScale(points, scalars)
+
+			aliased := make([]Point, len(points))
+			copy(aliased, points)
+			aliased[0].MultiScale(aliased, scalars)
+			Expect(aliased[0].Eq(&expected)).To(BeTrue())
+
+			copy(aliased, points)
+			aliased[0].MultiScaleVar(aliased, scalars)
+			Expect(aliased[0].Eq(&expected)).To(BeTrue())
+		}
+	})
+
+	It("should panic when the slices have different lengths", func() {
+		var p Point
+		points, scalars := randomPointsAndScalars(3)
+		Expect(func() { p.MultiScale(points, scalars[:2]) }).To(Panic())
+		Expect(func() { p.MultiScaleVar(points[:2], scalars) }).To(Panic())
+	})
+})
+
+var _ = Describe("Double base exponentiation", func() {
+	trials := 1000
+
+	inf := NewPointInfinity()
+
+	It("should compute the same result as exponentiating, scaling and adding", func() {
+		var a, b Fn
+		var P, aG, bP, expected, actual Point
+		for i := 0; i < trials; i++ {
+			a, b = RandomFn(), RandomFn()
+			P = RandomPoint()
+
+			aG.BaseExp(&a)
+			bP.Scale(&P, &b)
+			expected.Add(&aG, &bP)
+
+			actual.DoubleBaseExpVar(&a, &P, &b)
+			Expect(actual.Eq(&expected)).To(BeTrue())
+		}
+	})
+
+	It("should handle the point at infinity and zero scalars", func() {
+		var a, b, zero Fn
+		var P, expected, actual Point
+		for i := 0; i < trials; i++ {
+			a, b = RandomFn(), RandomFn()
+			P = RandomPoint()
+
+			expected.BaseExp(&a)
+			actual.DoubleBaseExpVar(&a, &inf, &b)
+			Expect(actual.Eq(&expected)).To(BeTrue())
+
+			actual.DoubleBaseExpVar(&a, &P, &zero)
+			Expect(actual.Eq(&expected)).To(BeTrue())
+
+			expected.Scale(&P, &b)
+			actual.DoubleBaseExpVar(&zero, &P, &b)
+			Expect(actual.Eq(&expected)).To(BeTrue())
+
+			actual.DoubleBaseExpVar(&zero, &inf, &b)
+			Expect(actual.IsInfinity()).To(BeTrue())
+		}
+	})
+
+	It("should compute correctly when the point argument is an alias of the receiver", func() {
+		var a, b Fn
+		var P, aliased, expected Point
+		for i := 0; i < trials; i++ {
+			a, b = RandomFn(), RandomFn()
+			P = RandomPoint()
+			aliased = P
+
+			expected.DoubleBaseExpVarUnsafe(&a, &P, &b)
+			aliased.DoubleBaseExpVarUnsafe(&a, &aliased, &b)
+			Expect(aliased.Eq(&expected)).To(BeTrue())
+		}
+	})
+
+	It("should panic when any argument is nil", func() {
+		var p Point
+		Expect(func() { p.DoubleBaseExpVar(nil, &Point{}, &Fn{}) }).To(Panic())
+		Expect(func() { p.DoubleBaseExpVar(&Fn{}, nil, &Fn{}) }).To(Panic())
+		Expect(func() { p.DoubleBaseExpVar(&Fn{}, &Point{}, nil) }).To(Panic())
+	})
+
+	Specify("double base exponentiation should be the same as the unsafe variant", func() {
+		var a, b Fn
+		var P, safe, unsafe Point
+		for i := 0; i < trials; i++ {
+			a, b = RandomFn(), RandomFn()
+			P = RandomPoint()
+
+			safe.DoubleBaseExpVar(&a, &P, &b)
+			unsafe.DoubleBaseExpVarUnsafe(&a, &P, &b)
+			Expect(safe.Eq(&unsafe)).To(BeTrue())
+		}
+	})
+})
+
+var _ = Describe("Base exponentiation blinding", func() {
+	trials := 100
+
+	// The canonical generator of the curve.
+	var G Point
+	{
+		one := NewFnFromU16(1)
+		G.BaseExp(&one)
+	}
+
+	randomSeed := func() []byte {
+		seed := make([]byte, 32)
+		if _, err := rand.Read(seed); err != nil {
+			panic(err)
+		}
+		return seed
+	}
+
+	It("should compute the same result after reseeding the blinding", func() {
+		var scalar Fn
+		var expected, actual Point
+		for i := 0; i < trials; i++ {
+			scalar = RandomFn()
+			expected.Scale(&G, &scalar)
+
+			ReseedBaseExpBlinding(randomSeed())
+			actual.BaseExp(&scalar)
+			Expect(actual.Eq(&expected)).To(BeTrue())
+		}
+
+		ReseedBaseExpBlinding(nil)
+		actual.BaseExp(&scalar)
+		Expect(actual.Eq(&expected)).To(BeTrue())
+	})
+
+	It("should compute correctly when reseeding concurrently", func() {
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				var scalar Fn
+				var expected, actual Point
+				for i := 0; i < trials; i++ {
+					scalar = RandomFn()
+					expected.Scale(&G, &scalar)
+					actual.BaseExp(&scalar)
+					Expect(actual.Eq(&expected)).To(BeTrue())
+				}
+			}()
+		}
+		for i := 0; i < trials; i++ {
+			ReseedBaseExpBlinding(randomSeed())
+		}
+		wg.Wait()
+	})
+
+	It("should panic when the seed is too short", func() {
+		var bs [31]byte
+		for i := 0; i < 31; i++ {
+			Expect(func() { ReseedBaseExpBlinding(bs[:i]) }).To(Panic())
+		}
+	})
+})
+
+func benchmarkMultiScale(b *testing.B, n int, f func(*Point, []Point, []Fn)) {
+	var p Point
+	points := make([]Point, n)
+	scalars := make([]Fn, n)
+	for i := range points {
+		points[i] = RandomPoint()
+		scalars[i] = RandomFn()
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f(&p, points, scalars)
+	}
+}
+
+func BenchmarkMultiScale100(b *testing.B) {
+	benchmarkMultiScale(b, 100, (*Point).MultiScale)
+}
+
+func BenchmarkMultiScaleVar100(b *testing.B) {
+	benchmarkMultiScale(b, 100, (*Point).MultiScaleVar)
+}
+
+func BenchmarkMultiScaleVar1000(b *testing.B) {
+	benchmarkMultiScale(b, 1000, (*Point).MultiScaleVar)
+}
+
+func BenchmarkDoubleBaseExpVar(b *testing.B) {
+	var p Point
+	x, y := RandomFn(), RandomFn()
+	P := RandomPoint()
+
+	for i := 0; i < b.N; i++ {
+		p.DoubleBaseExpVarUnsafe(&x, &P, &y)
+	}
+}
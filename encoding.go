@@ -0,0 +1,54 @@
+package secp256k1
+
+import (
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// marshalHexText returns the hex encoding of the binary representation of the
+// given value.
+func marshalHexText(m encoding.BinaryMarshaler) ([]byte, error) {
+	bs, err := m.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	text := make([]byte, hex.EncodedLen(len(bs)))
+	hex.Encode(text, bs)
+	return text, nil
+}
+
+// unmarshalHexText decodes the given hex text and sets the given value from
+// the resulting binary representation.
+func unmarshalHexText(u encoding.BinaryUnmarshaler, text []byte) error {
+	bs := make([]byte, hex.DecodedLen(len(text)))
+	if _, err := hex.Decode(bs, text); err != nil {
+		return err
+	}
+	return u.UnmarshalBinary(bs)
+}
+
+// marshalJSONText returns the text representation of the given value as a
+// JSON string.
+func marshalJSONText(m encoding.TextMarshaler) ([]byte, error) {
+	text, err := m.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// unmarshalJSONText sets the given value from the text representation
+// contained in the given JSON string. As is conventional for encoding/json,
+// the JSON null value leaves the value unchanged.
+func unmarshalJSONText(u encoding.TextUnmarshaler, data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	return u.UnmarshalText([]byte(text))
+}
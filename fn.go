@@ -16,11 +16,143 @@ package secp256k1
 #include "secp256k1/src/scalar_impl.h"
 #include "secp256k1/src/scalar_4x64_impl.h"
 
+// Sets r to the 64 byte big endian number b64 reduced modulo N.
+void scalar_set_b64(secp256k1_scalar *r, const unsigned char *b64) {
+	uint64_t l[8];
+	int i, j;
+
+	// The limbs are little endian.
+	for (i = 0; i < 8; i++) {
+		l[i] = 0;
+		for (j = 0; j < 8; j++) {
+			l[i] = (l[i] << 8) | b64[56 - 8*i + j];
+		}
+	}
+	secp256k1_scalar_reduce_512(r, l);
+	memset(l, 0, sizeof(l));
+}
+
+// Sets r to a raised to the power of e. This function is constant time with
+// respect to the values of a and e.
+void scalar_exp(secp256k1_scalar *r, const secp256k1_scalar *a, const secp256k1_scalar *e) {
+	secp256k1_scalar acc, tmp, base;
+	int i;
+
+	base = *a;
+	acc = secp256k1_scalar_one;
+	for (i = 255; i >= 0; i--) {
+		secp256k1_scalar_sqr(&acc, &acc);
+		secp256k1_scalar_mul(&tmp, &acc, &base);
+		secp256k1_scalar_cmov(&acc, &tmp, secp256k1_scalar_get_bits(e, i, 1));
+	}
+	*r = acc;
+}
+
+// Sets r to a raised to the power of e. This function is not constant time.
+void scalar_exp_var(secp256k1_scalar *r, const secp256k1_scalar *a, const secp256k1_scalar *e) {
+	secp256k1_scalar acc, base;
+	int i;
+
+	base = *a;
+	acc = secp256k1_scalar_one;
+	for (i = 255; i >= 0; i--) {
+		if (!secp256k1_scalar_is_one(&acc)) {
+			secp256k1_scalar_sqr(&acc, &acc);
+		}
+		if (secp256k1_scalar_get_bits(e, i, 1)) {
+			secp256k1_scalar_mul(&acc, &acc, &base);
+		}
+	}
+	*r = acc;
+}
+
+// The group order N satisfies N - 1 = 2^6 * Q for odd Q. These are the
+// exponent (Q - 1)/2 and the primitive 2^6th root of unity 5^Q, where 5 is the
+// smallest quadratic non residue.
+static const secp256k1_scalar scalar_sqrt_exp = SECP256K1_SCALAR_CONST(
+	0x01FFFFFF, 0xFFFFFFFF, 0xFFFFFFFF, 0xFFFFFFFF,
+	0xFD755DB9, 0xCD5E9140, 0x777FA4BD, 0x19A06C82
+);
+static const secp256k1_scalar scalar_sqrt_root = SECP256K1_SCALAR_CONST(
+	0x0D1F8EAB, 0x98DCD1AC, 0xA7DC810E, 0x065710CB,
+	0xB96E9ABE, 0xBBE451FA, 0x15B4F83D, 0x2D2AD232
+);
+
+// Sets r to the square root of a that is not high using the Tonelli-Shanks
+// algorithm, and returns 1 if a is a square and 0 otherwise. If a is not a
+// square, r is set to an unspecified value. The arguments must not alias each
+// other. This function is constant time with respect to the value of a.
+int scalar_sqrt(secp256k1_scalar *r, const secp256k1_scalar *a) {
+	secp256k1_scalar w, b, z, t, tmp;
+	int i, j;
+
+	// Invariants: r^2 = a*b, z has order 2^i and, if a is a square, the order
+	// of b divides 2^(i-1).
+	scalar_exp(&w, a, &scalar_sqrt_exp);
+	secp256k1_scalar_mul(r, a, &w);
+	secp256k1_scalar_mul(&b, r, &w);
+	z = scalar_sqrt_root;
+	for (i = 6; i >= 2; i--) {
+		t = b;
+		for (j = 0; j < i - 2; j++) {
+			secp256k1_scalar_sqr(&t, &t);
+		}
+
+		// If t is not one then it is -1, and multiplying b by z^2 reduces its
+		// order.
+		secp256k1_scalar_mul(&tmp, r, &z);
+		secp256k1_scalar_cmov(r, &tmp, !secp256k1_scalar_is_one(&t));
+		secp256k1_scalar_sqr(&z, &z);
+		secp256k1_scalar_mul(&tmp, &b, &z);
+		secp256k1_scalar_cmov(&b, &tmp, !secp256k1_scalar_is_one(&t));
+	}
+	secp256k1_scalar_cond_negate(r, secp256k1_scalar_is_high(r));
+
+	secp256k1_scalar_sqr(&t, r);
+	return secp256k1_scalar_eq(&t, a);
+}
+
+// Replaces each of the n scalars by its inverse using Montgomery's trick, which
+// requires a single inversion. Zero scalars are left as zero. The prods array
+// is used as scratch space and must have room for n scalars. This function is
+// constant time with respect to the values of the scalars.
+void scalar_batch_inverse(secp256k1_scalar *xs, secp256k1_scalar *prods, size_t n) {
+	secp256k1_scalar acc, inv, tmp, x;
+	size_t i;
+	int zero;
+
+	if (n == 0) {
+		return;
+	}
+
+	// prods[i] is the product of the non zero scalars before the ith one.
+	acc = secp256k1_scalar_one;
+	for (i = 0; i < n; i++) {
+		x = xs[i];
+		zero = secp256k1_scalar_is_zero(&x);
+		secp256k1_scalar_cmov(&x, &secp256k1_scalar_one, zero);
+		prods[i] = acc;
+		secp256k1_scalar_mul(&acc, &acc, &x);
+	}
+
+	secp256k1_scalar_inverse(&inv, &acc);
+
+	for (i = n; i-- > 0;) {
+		x = xs[i];
+		zero = secp256k1_scalar_is_zero(&x);
+		secp256k1_scalar_cmov(&x, &secp256k1_scalar_one, zero);
+		secp256k1_scalar_mul(&tmp, &inv, &prods[i]);
+		secp256k1_scalar_mul(&inv, &inv, &x);
+		secp256k1_scalar_cmov(&xs[i], &tmp, !zero);
+	}
+}
+
 */
 import "C"
 import (
 	"crypto/rand"
 	"fmt"
+	"io"
 	"math/big"
 	"unsafe"
 
@@ -34,6 +166,9 @@ const FnSize int = int(unsafe.Sizeof(Fn{}))
 // curve point.
 const FnSizeMarshalled int = 32
 
+// The order of the elliptic curve group secp256k1.
+var nInt, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+
 // Fn represents an element of the field defined by the prime N, where N is the
 // order of the elliptic curve group secp256k1.
 type Fn struct {
@@ -48,6 +183,14 @@ func NewFnFromU16(v uint16) Fn {
 	return x
 }
 
+// NewFnFromInt returns a new field element equal to the given integer reduced
+// modulo N.
+func NewFnFromInt(v *big.Int) Fn {
+	x := Fn{}
+	x.SetInt(v)
+	return x
+}
+
 // RandomFn returns a random field element.
 //
 // Panics: This function will panic if there was an error reading bytes from
@@ -62,16 +205,27 @@ func RandomFn() Fn {
 
 // RandomFnNoPanic returns a random field element or an error.
 func RandomFnNoPanic() (Fn, error) {
-	var bs [32]byte
-	_, err := rand.Read(bs[:])
+	return RandomFnFrom(rand.Reader)
+}
+
+// RandomFnFrom returns a random field element using the given source of
+// randomness, or an error if it could not read from the source. The result is
+// uniform if the source is, and deterministic if the source is.
+func RandomFnFrom(r io.Reader) (Fn, error) {
+	var bs [64]byte
+	_, err := io.ReadFull(r, bs[:])
 	if err != nil {
 		return Fn{}, err
 	}
 	x := Fn{}
 
-	// This will reduce the value modulo N, so it does not matter if the bytes
-	// represent a number greater than N.
-	x.SetB32(bs[:])
+	// Reducing 512 random bits modulo N gives a result whose distribution
+	// differs from uniform by at most 2^-256, whereas reducing only 256 bits
+	// would make the smallest values noticeably more likely.
+	x.SetB64(bs[:])
+	for i := range bs {
+		bs[i] = 0
+	}
 	return x, nil
 }
 
@@ -113,6 +267,61 @@ func (x *Fn) SetB32(bs []byte) bool {
 	return overflow != 0
 }
 
+// SetB64 sets the field element to be equal to the given byte slice,
+// interpreted as a 64 byte big endian number, reduced modulo N. If the bytes
+// are uniformly random, the distribution of the result is statistically
+// indistinguishable from uniform, which makes this suitable for hashing and
+// sampling into the field.
+//
+// Panics: If the byte slice has length less than 64, this function will panic.
+func (x *Fn) SetB64(bs []byte) {
+	if len(bs) < 64 {
+		panic(fmt.Sprintf("invalid slice length: length needs to be at least 64, got %v", len(bs)))
+	}
+
+	C.scalar_set_b64(&x.inner, (*C.uchar)(&bs[0]))
+}
+
+// SetBytesWide sets the field element to be equal to the given byte slice,
+// interpreted as big endian, reduced modulo N. The byte slice can have any
+// length up to 64 bytes, so for example the 48 byte outputs of the RFC 9380
+// expand_message functions can be used directly.
+//
+// Panics: If the byte slice has length greater than 64, this function will
+// panic.
+func (x *Fn) SetBytesWide(bs []byte) {
+	if len(bs) > 64 {
+		panic(fmt.Sprintf("invalid slice length: length needs to be at most 64, got %v", len(bs)))
+	}
+
+	var wide [64]byte
+	copy(wide[64-len(bs):], bs)
+	x.SetB64(wide[:])
+}
+
+// SetB32Canonical sets the field element to be equal to the given byte slice,
+// interpreted as big endian. Unlike SetB32, the value is not reduced: if the
+// bytes represent a number greater than or equal to N, a *NonCanonicalError
+// is returned and the receiver is left unchanged.
+//
+// Panics: If the byte slice has length less than 32, this function will panic.
+func (x *Fn) SetB32Canonical(bs []byte) error {
+	if len(bs) < 32 {
+		panic(fmt.Sprintf("invalid slice length: length needs to be at least 32, got %v", len(bs)))
+	}
+
+	var tmp C.secp256k1_scalar
+	var overflow C.int
+
+	C.secp256k1_scalar_set_b32(&tmp, (*C.uchar)(&bs[0]), &overflow)
+	if overflow != 0 {
+		return &NonCanonicalError{Type: "Fn"}
+	}
+
+	x.inner = tmp
+	return nil
+}
+
 // SetB32SecKey sets the receiver from the given byte slice, intepreted in big
 // endian form, and returns a bool indicating whether the bytes represent a
 // valid private key. The bytes don't represent a valid private key if either
@@ -154,7 +363,9 @@ func (x Fn) Marshal(buf []byte, rem int) ([]byte, int, error) {
 	return buf[FnSizeMarshalled:], rem - FnSizeMarshalled, nil
 }
 
-// Unmarshal implements the surge.Unmarshaler interface.
+// Unmarshal implements the surge.Unmarshaler interface. Bytes that represent a
+// number greater than or equal to N are reduced modulo N. Use
+// UnmarshalStrict or StrictFn to reject them instead.
 func (x *Fn) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	if len(buf) < FnSizeMarshalled || rem < FnSize {
 		return buf, rem, surge.ErrUnexpectedEndOfBuffer
@@ -165,12 +376,115 @@ func (x *Fn) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	return buf[FnSizeMarshalled:], rem - FnSize, nil
 }
 
+// UnmarshalStrict is the same as Unmarshal, except that it returns a
+// *NonCanonicalError if the bytes represent a number greater than or equal to
+// N. In this case the receiver is left unchanged. To select strict decoding
+// for a field element nested in another type, use StrictFn.
+func (x *Fn) UnmarshalStrict(buf []byte, rem int) ([]byte, int, error) {
+	if len(buf) < FnSizeMarshalled || rem < FnSize {
+		return buf, rem, surge.ErrUnexpectedEndOfBuffer
+	}
+
+	if err := x.SetB32Canonical(buf[:FnSizeMarshalled]); err != nil {
+		return buf, rem, err
+	}
+
+	return buf[FnSizeMarshalled:], rem - FnSize, nil
+}
+
+// String implements the fmt.Stringer interface. The field element is printed
+// as the hex string of its 32 byte big endian representation.
+func (x Fn) String() string {
+	return fmt.Sprint(x)
+}
+
+// Format implements the fmt.Formatter interface. The %x and %X verbs print the
+// 32 byte big endian representation of the field element in hex, with a 0x
+// prefix if the # flag is given, %d prints the field element in decimal, and
+// %v and %s print the same as %x. A width can be given to pad the result.
+func (x Fn) Format(f fmt.State, verb rune) {
+	var bs [32]byte
+	x.PutB32(bs[:])
+	formatField(f, verb, x, bs[:])
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface. The field
+// element is encoded as 32 big endian bytes.
+func (x Fn) MarshalBinary() ([]byte, error) {
+	bs := make([]byte, FnSizeMarshalled)
+	x.PutB32(bs)
+	return bs, nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface. An
+// error is returned if the data does not have length 32, or if it represents a
+// number greater than or equal to N.
+func (x *Fn) UnmarshalBinary(data []byte) error {
+	if len(data) != FnSizeMarshalled {
+		return fmt.Errorf("invalid length: expected %v bytes, got %v", FnSizeMarshalled, len(data))
+	}
+	return x.SetB32Canonical(data)
+}
+
+// MarshalText implements the encoding.TextMarshaler interface. The field
+// element is encoded as the hex string of its binary representation.
+func (x Fn) MarshalText() ([]byte, error) {
+	return marshalHexText(x)
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface, with the
+// same checks as UnmarshalBinary.
+func (x *Fn) UnmarshalText(text []byte) error {
+	return unmarshalHexText(x, text)
+}
+
+// MarshalJSON implements the json.Marshaler interface. The field element is
+// encoded as a JSON string containing its text representation.
+func (x Fn) MarshalJSON() ([]byte, error) {
+	return marshalJSONText(x)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, with the same
+// checks as UnmarshalBinary.
+func (x *Fn) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(x, data)
+}
+
 // SetU16 sets the field element to be equal to the given uint.
 func (x *Fn) SetU16(v uint16) {
-	// TODO: Currently we take a uint16 as an argument because a c uint is only
-	// guaranteed to have at least 16 bits. Consider changing the struct to
-	// have x.inner be [4]uint64 to avoid this potential information loss.
-	C.secp256k1_scalar_set_int(&x.inner, C.uint(v))
+	x.SetU64(uint64(v))
+}
+
+// SetU64 sets the field element to be equal to the given unsigned integer.
+func (x *Fn) SetU64(v uint64) {
+	// Don't call out to c here as the implementation is simple enough to
+	// warrant avoiding the FFI overhead. A c uint is only guaranteed to have
+	// at least 16 bits, so secp256k1_scalar_set_int could also lose
+	// information.
+
+	// Any 64 bit value is less than N, so it can be put directly in the lowest
+	// limb.
+	x.inner.d[0] = C.uint64_t(v)
+	x.inner.d[1] = 0
+	x.inner.d[2] = 0
+	x.inner.d[3] = 0
+}
+
+// SetInt sets the field element to be equal to the given integer reduced
+// modulo N. Negative integers are reduced to their non negative
+// representative, so for example -1 is mapped to N - 1.
+func (x *Fn) SetInt(v *big.Int) {
+	if v == nil {
+		panic("expected first argument to be not be nil")
+	}
+
+	if v.Sign() < 0 || v.Cmp(nInt) >= 0 {
+		v = new(big.Int).Mod(v, nInt)
+	}
+
+	var bs [32]byte
+	v.FillBytes(bs[:])
+	x.SetB32(bs[:])
 }
 
 // Add computes the addition of the two field elements and stores the result in
@@ -244,6 +558,93 @@ func (x *Fn) SqrUnsafe(a *Fn) {
 	C.secp256k1_scalar_sqr(&x.inner, &a.inner)
 }
 
+// Exp computes the given field element raised to the power of the given
+// exponent and stores the result in the receiver. The computation uses a time
+// invariant algorithm, so it is suitable for secret exponents.
+func (x *Fn) Exp(a, e *Fn) {
+	if a == nil {
+		panic("expected first argument to be not be nil")
+	}
+	if e == nil {
+		panic("expected second argument to be not be nil")
+	}
+
+	x.ExpUnsafe(a, e)
+}
+
+// ExpUnsafe computes the given field element raised to the power of the given
+// exponent and stores the result in the receiver. The computation uses a time
+// invariant algorithm, so it is suitable for secret exponents.
+//
+// Unsafe: If this function receives nil arguments, the behaviour is
+// implementation dependent, because the definition of the NULL pointer in c is
+// implementation dependent. If the NULL pointer and the go nil pointer are the
+// same, then the function will panic.
+func (x *Fn) ExpUnsafe(a, e *Fn) {
+	C.scalar_exp(&x.inner, &a.inner, &e.inner)
+}
+
+// ExpVar computes the given field element raised to the power of the given
+// exponent and stores the result in the receiver. The computation is not time
+// invariant, so it should only be used when the exponent and base are public.
+func (x *Fn) ExpVar(a, e *Fn) {
+	if a == nil {
+		panic("expected first argument to be not be nil")
+	}
+	if e == nil {
+		panic("expected second argument to be not be nil")
+	}
+
+	x.ExpVarUnsafe(a, e)
+}
+
+// ExpVarUnsafe computes the given field element raised to the power of the
+// given exponent and stores the result in the receiver. The computation is not
+// time invariant, so it should only be used when the exponent and base are
+// public.
+//
+// Unsafe: If this function receives nil arguments, the behaviour is
+// implementation dependent, because the definition of the NULL pointer in c is
+// implementation dependent. If the NULL pointer and the go nil pointer are the
+// same, then the function will panic.
+func (x *Fn) ExpVarUnsafe(a, e *Fn) {
+	C.scalar_exp_var(&x.inner, &a.inner, &e.inner)
+}
+
+// Sqrt computes a square root of the given field element and stores the result
+// in the receiver, and returns true if the field element is a square. If it is
+// not a square, false is returned and the receiver is left unchanged. Of the
+// two square roots, the one that is not high is chosen. The computation uses a
+// time invariant algorithm.
+func (x *Fn) Sqrt(a *Fn) bool {
+	if a == nil {
+		panic("expected first argument to be not be nil")
+	}
+
+	return x.SqrtUnsafe(a)
+}
+
+// SqrtUnsafe computes a square root of the given field element and stores the
+// result in the receiver, and returns true if the field element is a square.
+// If it is not a square, false is returned and the receiver is left unchanged.
+// Of the two square roots, the one that is not high is chosen. The computation
+// uses a time invariant algorithm.
+//
+// Unsafe: If this function receives nil arguments, the behaviour is
+// implementation dependent, because the definition of the NULL pointer in c is
+// implementation dependent. If the NULL pointer and the go nil pointer are the
+// same, then the function will panic.
+func (x *Fn) SqrtUnsafe(a *Fn) bool {
+	// The c function does not allow the arguments to alias each other.
+	var root C.secp256k1_scalar
+	if C.scalar_sqrt(&root, &a.inner) == 0 {
+		return false
+	}
+
+	x.inner = root
+	return true
+}
+
 // InverseInvar computes the multiplicative inverse of the given field element
 // using a time invariant algorithm and stores the result in the receiver.
 func (x *Fn) InverseInvar(a *Fn) {
@@ -287,6 +688,21 @@ func (x *Fn) InverseUnsafe(a *Fn) {
 	C.secp256k1_scalar_inverse_var(&x.inner, &a.inner)
 }
 
+// BatchInverseFn replaces each of the given field elements by its
+// multiplicative inverse. This is significantly faster than inverting each of
+// the field elements individually, as only a single inversion is computed.
+// Field elements that are zero are left unchanged, which is consistent with
+// the inverse functions for a single field element. The computation uses a
+// time invariant algorithm.
+func BatchInverseFn(xs []Fn) {
+	if len(xs) == 0 {
+		return
+	}
+
+	prods := make([]Fn, len(xs))
+	C.scalar_batch_inverse(&xs[0].inner, &prods[0].inner, C.size_t(len(xs)))
+}
+
 // Negate computes the additive inverse of the given field element and stores
 // the result in the receiver.
 func (x *Fn) Negate(a *Fn) {
@@ -1,14 +1,21 @@
 package secp256k1_test
 
 import (
+	"bytes"
 	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"math/big"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/renproject/secp256k1"
 	"github.com/renproject/secp256k1/secp256k1tutil"
+	"github.com/renproject/surge"
 )
 
 var _ = Describe("Fn", func() {
@@ -154,6 +161,185 @@ var _ = Describe("Fn", func() {
 		}
 	})
 
+	It("should batch invert correctly", func() {
+		for _, n := range []int{0, 1, 2, 3, 10, 100} {
+			xs := make([]Fn, n)
+			expected := make([]Fn, n)
+			for i := range xs {
+				xs[i] = RandomFn()
+				expected[i].InverseUnsafe(&xs[i])
+			}
+
+			BatchInverseFn(xs)
+			for i := range xs {
+				Expect(xs[i].Eq(&expected[i])).To(BeTrue())
+			}
+		}
+	})
+
+	It("should leave zero elements unchanged when batch inverting", func() {
+		for i := 0; i < trials/10; i++ {
+			xs := make([]Fn, 10)
+			expected := make([]Fn, 10)
+			for j := range xs {
+				if (i+j)%3 != 0 {
+					xs[j] = RandomFn()
+				}
+				expected[j].InverseUnsafe(&xs[j])
+			}
+
+			BatchInverseFn(xs)
+			for j := range xs {
+				Expect(xs[j].Eq(&expected[j])).To(BeTrue())
+				if (i+j)%3 == 0 {
+					Expect(xs[j].IsZero()).To(BeTrue())
+				}
+			}
+		}
+
+		xs := make([]Fn, 5)
+		BatchInverseFn(xs)
+		for j := range xs {
+			Expect(xs[j].IsZero()).To(BeTrue())
+		}
+	})
+
+	It("should exponentiate correctly", func() {
+		var a, e, b Fn
+		x, y, expected, actual := new(big.Int), new(big.Int), new(big.Int), new(big.Int)
+		for i := 0; i < trials/10; i++ {
+			a, e = RandomFn(), RandomFn()
+			a.PutInt(x)
+			e.PutInt(y)
+			expected.Exp(x, y, N)
+
+			b.ExpUnsafe(&a, &e)
+			b.PutInt(actual)
+			Expect(actual.Cmp(expected)).To(Equal(0))
+
+			b.ExpVarUnsafe(&a, &e)
+			b.PutInt(actual)
+			Expect(actual.Cmp(expected)).To(Equal(0))
+		}
+	})
+
+	It("should exponentiate correctly for small exponents", func() {
+		var a, e, b, expected Fn
+		for i := 0; i < trials/10; i++ {
+			a = RandomFn()
+			expected = one
+			for j := uint16(0); j < 10; j++ {
+				e = NewFnFromU16(j)
+
+				b.Exp(&a, &e)
+				Expect(b.Eq(&expected)).To(BeTrue())
+
+				b.ExpVar(&a, &e)
+				Expect(b.Eq(&expected)).To(BeTrue())
+
+				expected.Mul(&expected, &a)
+			}
+		}
+	})
+
+	It("should exponentiate correctly when the base is zero", func() {
+		var e, b Fn
+		for i := 0; i < trials/10; i++ {
+			e = RandomFn()
+
+			b.Exp(&zero, &e)
+			Expect(b.IsZero()).To(BeTrue())
+
+			b.ExpVar(&zero, &e)
+			Expect(b.IsZero()).To(BeTrue())
+		}
+
+		b.Exp(&zero, &zero)
+		Expect(b.IsOne()).To(BeTrue())
+		b.ExpVar(&zero, &zero)
+		Expect(b.IsOne()).To(BeTrue())
+	})
+
+	It("should compute square roots correctly", func() {
+		var a, root Fn
+		x, expected, actual := new(big.Int), new(big.Int), new(big.Int)
+		for i := 0; i < trials; i++ {
+			a = RandomFn()
+			a.PutInt(x)
+
+			root = Fn{}
+			if expected.ModSqrt(x, N) == nil {
+				Expect(root.Sqrt(&a)).To(BeFalse())
+
+				// The receiver is left unchanged.
+				Expect(root.IsZero()).To(BeTrue())
+				continue
+			}
+			Expect(root.Sqrt(&a)).To(BeTrue())
+
+			// The root that is not high is chosen.
+			if expected.Cmp(N2Int) > 0 {
+				expected.Sub(N, expected)
+			}
+			root.PutInt(actual)
+			Expect(actual.Cmp(expected)).To(Equal(0))
+			Expect(root.IsHigh()).To(BeFalse())
+		}
+	})
+
+	It("should compute the square root of a square", func() {
+		var a, b, root, negated Fn
+		for i := 0; i < trials; i++ {
+			a = RandomFn()
+			b.Sqr(&a)
+
+			Expect(root.Sqrt(&b)).To(BeTrue())
+
+			negated.Negate(&a)
+			Expect(root.Eq(&a) || root.Eq(&negated)).To(BeTrue())
+		}
+
+		Expect(root.Sqrt(&zero)).To(BeTrue())
+		Expect(root.IsZero()).To(BeTrue())
+
+		// -1 is a square because N = 1 mod 4.
+		var minusOne Fn
+		minusOne.Negate(&one)
+		Expect(root.Sqrt(&minusOne)).To(BeTrue())
+		root.Sqr(&root)
+		Expect(root.Eq(&minusOne)).To(BeTrue())
+	})
+
+	It("should compute square roots correctly when the argument is an alias of the receiver", func() {
+		var a, b, expected Fn
+		for i := 0; i < trials; i++ {
+			a = RandomFn()
+			b = a
+			ok := expected.Sqrt(&a)
+			Expect(b.Sqrt(&b)).To(Equal(ok))
+			if ok {
+				Expect(b.Eq(&expected)).To(BeTrue())
+			} else {
+				Expect(b.Eq(&a)).To(BeTrue())
+			}
+		}
+	})
+
+	It("should panic when computing a square root when the argument is nil", func() {
+		var x Fn
+		Expect(func() { x.Sqrt(nil) }).To(Panic())
+	})
+
+	Specify("square roots should be the same as the unsafe variant", func() {
+		var x, safe, unsafe Fn
+		for i := 0; i < trials; i++ {
+			x = RandomFn()
+
+			Expect(safe.Sqrt(&x)).To(Equal(unsafe.SqrtUnsafe(&x)))
+			Expect(safe.Eq(&unsafe)).To(BeTrue())
+		}
+	})
+
 	//
 	// Properties
 	//
@@ -353,6 +539,14 @@ var _ = Describe("Fn", func() {
 		Expect(func() { x.Inverse(nil) }).To(Panic())
 	})
 
+	It("should panic when exponentiating when either argument is nil", func() {
+		var x Fn
+		Expect(func() { x.Exp(nil, &Fn{}) }).To(Panic())
+		Expect(func() { x.Exp(&Fn{}, nil) }).To(Panic())
+		Expect(func() { x.ExpVar(nil, &Fn{}) }).To(Panic())
+		Expect(func() { x.ExpVar(&Fn{}, nil) }).To(Panic())
+	})
+
 	It("should panic when negating when the argument is nil", func() {
 		var x Fn
 		Expect(func() { x.Negate(nil) }).To(Panic())
@@ -374,6 +568,15 @@ var _ = Describe("Fn", func() {
 		}
 	})
 
+	It("should panic when setting bytes (wide) when the slice length is invalid", func() {
+		var x Fn
+		for i := 0; i < 64; i++ {
+			bs := make([]byte, i)
+			Expect(func() { x.SetB64(bs) }).To(Panic())
+		}
+		Expect(func() { x.SetBytesWide(make([]byte, 65)) }).To(Panic())
+	})
+
 	It("should panic when putting bytes when the slice length is too small", func() {
 		var x Fn
 		var bs [31]byte
@@ -423,6 +626,42 @@ var _ = Describe("Fn", func() {
 		}
 	})
 
+	It("should be one after raising to the power of N - 1", func() {
+		var a, e, b Fn
+		e.Negate(&one)
+		for i := 0; i < trials/10; i++ {
+			a = RandomFn()
+			if a.IsZero() {
+				continue
+			}
+
+			b.Exp(&a, &e)
+			Expect(b.IsOne()).To(BeTrue())
+		}
+	})
+
+	It("should be equal after exponentiating when the base is an alias of the receiver", func() {
+		var a, e, expected Fn
+		for i := 0; i < trials/10; i++ {
+			a, e = RandomFn(), RandomFn()
+			expected.Exp(&a, &e)
+
+			a.Exp(&a, &e)
+			Expect(a.Eq(&expected)).To(BeTrue())
+		}
+	})
+
+	It("should be equal after exponentiating when the exponent is an alias of the receiver", func() {
+		var a, e, expected Fn
+		for i := 0; i < trials/10; i++ {
+			a, e = RandomFn(), RandomFn()
+			expected.ExpVar(&a, &e)
+
+			e.ExpVar(&a, &e)
+			Expect(e.Eq(&expected)).To(BeTrue())
+		}
+	})
+
 	It("should be one after multiplying by the multiplicative inverse", func() {
 		var x, y Fn
 		for i := 0; i < trials; i++ {
@@ -514,6 +753,148 @@ var _ = Describe("Fn", func() {
 		}
 	})
 
+	It("should be equal to the given unsigned integer", func() {
+		var x Fn
+		v, actual := new(big.Int), new(big.Int)
+		for i := 0; i < trials; i++ {
+			var bs [8]byte
+			if _, err := rand.Read(bs[:]); err != nil {
+				panic(err)
+			}
+			u := binary.BigEndian.Uint64(bs[:])
+
+			x.SetU64(u)
+			x.PutInt(actual)
+			v.SetUint64(u)
+			Expect(actual.Cmp(v)).To(Equal(0))
+		}
+
+		x.SetU64(^uint64(0))
+		x.PutInt(actual)
+		v.SetUint64(^uint64(0))
+		Expect(actual.Cmp(v)).To(Equal(0))
+
+		x.SetU16(0xFFFF)
+		y := NewFnFromU16(0xFFFF)
+		x.PutInt(actual)
+		Expect(actual.Int64()).To(Equal(int64(0xFFFF)))
+		Expect(y.Eq(&x)).To(BeTrue())
+	})
+
+	It("should be equal to the given integer reduced modulo N", func() {
+		var x Fn
+		bound := new(big.Int).Lsh(big.NewInt(1), 512)
+		expected, actual := new(big.Int), new(big.Int)
+		for i := 0; i < trials; i++ {
+			v, err := rand.Int(rand.Reader, bound)
+			if err != nil {
+				panic(err)
+			}
+			if i%2 == 0 {
+				v.Neg(v)
+			}
+			expected.Mod(v, N)
+
+			x.SetInt(v)
+			x.PutInt(actual)
+			Expect(actual.Cmp(expected)).To(Equal(0))
+		}
+	})
+
+	It("should be unchanged after converting to and from a big.Int", func() {
+		var x, y Fn
+		for i := 0; i < trials; i++ {
+			x = RandomFn()
+			y.SetInt(x.Int())
+			Expect(y.Eq(&x)).To(BeTrue())
+		}
+	})
+
+	It("should reduce integers at the boundary of the field correctly", func() {
+		var x Fn
+
+		x.SetInt(N)
+		Expect(x.IsZero()).To(BeTrue())
+
+		x.SetInt(big.NewInt(-1))
+		var minusOne Fn
+		minusOne.Negate(&one)
+		Expect(x.Eq(&minusOne)).To(BeTrue())
+
+		x.SetInt(new(big.Int).Add(N, big.NewInt(1)))
+		Expect(x.Eq(&one)).To(BeTrue())
+
+		x.SetInt(new(big.Int).Neg(N))
+		Expect(x.IsZero()).To(BeTrue())
+	})
+
+	It("should construct field elements from integers", func() {
+		for i := 0; i < trials; i++ {
+			x := RandomFn()
+			y := NewFnFromInt(x.Int())
+			Expect(y.Eq(&x)).To(BeTrue())
+		}
+	})
+
+	It("should panic when setting from a nil big.Int", func() {
+		var x Fn
+		Expect(func() { x.SetInt(nil) }).To(Panic())
+	})
+
+	It("should be equal to 64 bytes reduced modulo N", func() {
+		var bs [64]byte
+		var x Fn
+		expected, actual := new(big.Int), new(big.Int)
+		for i := 0; i < trials; i++ {
+			if _, err := rand.Read(bs[:]); err != nil {
+				panic(err)
+			}
+			expected.SetBytes(bs[:])
+			expected.Mod(expected, N)
+
+			x.SetB64(bs[:])
+			x.PutInt(actual)
+			Expect(actual.Cmp(expected)).To(Equal(0))
+		}
+
+		for i := range bs {
+			bs[i] = 0xFF
+		}
+		expected.SetBytes(bs[:])
+		expected.Mod(expected, N)
+		x.SetB64(bs[:])
+		x.PutInt(actual)
+		Expect(actual.Cmp(expected)).To(Equal(0))
+	})
+
+	It("should be equal to bytes of any length up to 64 reduced modulo N", func() {
+		var x Fn
+		expected, actual := new(big.Int), new(big.Int)
+		for i := 0; i < trials; i++ {
+			bs := make([]byte, i%65)
+			if _, err := rand.Read(bs); err != nil {
+				panic(err)
+			}
+			expected.SetBytes(bs)
+			expected.Mod(expected, N)
+
+			x.SetBytesWide(bs)
+			x.PutInt(actual)
+			Expect(actual.Cmp(expected)).To(Equal(0))
+		}
+	})
+
+	It("should be equal after converting to and from 32 bytes with wide reduction", func() {
+		var bs [32]byte
+		var x, y Fn
+		for i := 0; i < trials; i++ {
+			x = RandomFn()
+			x.PutB32(bs[:])
+			y.SetBytesWide(bs[:])
+			Expect(y.Eq(&x)).To(BeTrue())
+		}
+	})
+
 	It("should be equal after marshaling and unmarshaling with surge", func() {
 		var bs [FnSizeMarshalled]byte
 		var before, after Fn
@@ -609,10 +990,223 @@ var _ = Describe("Fn", func() {
 		}
 	})
 
+	It("should set canonical bytes strictly", func() {
+		var bs [32]byte
+		var x, y Fn
+		for i := 0; i < trials; i++ {
+			x = RandomFn()
+			x.PutB32(bs[:])
+			Expect(y.SetB32Canonical(bs[:])).To(Succeed())
+			Expect(y.Eq(&x)).To(BeTrue())
+		}
+	})
+
+	It("should return an error when strictly setting bytes not less than N", func() {
+		var x, before Fn
+		for i := 0; i < trials; i++ {
+			x = RandomFn()
+			before = x
+
+			bs := randomOutOfRangeBytes()
+			err := x.SetB32Canonical(bs)
+			Expect(err).To(BeAssignableToTypeOf(&NonCanonicalError{}))
+
+			// The receiver is left unchanged.
+			Expect(x.Eq(&before)).To(BeTrue())
+		}
+
+		bs := make([]byte, 32)
+		N.FillBytes(bs)
+		Expect(x.SetB32Canonical(bs)).To(BeAssignableToTypeOf(&NonCanonicalError{}))
+	})
+
+	It("should return an error when strictly unmarshalling bytes not less than N", func() {
+		for i := 0; i < trials; i++ {
+			var x Fn
+			bs := randomOutOfRangeBytes()
+
+			tail, rem, err := x.UnmarshalStrict(bs, FnSize)
+			Expect(err).To(BeAssignableToTypeOf(&NonCanonicalError{}))
+			Expect(rem).To(Equal(FnSize))
+			Expect(len(tail)).To(Equal(FnSizeMarshalled))
+			Expect(x.IsZero()).To(BeTrue())
+
+			// The default decoding reduces the value.
+			tail, rem, err = x.Unmarshal(bs, FnSize)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rem).To(Equal(0))
+			Expect(len(tail)).To(Equal(0))
+		}
+	})
+
+	It("should be equal after marshaling and strictly unmarshaling with surge", func() {
+		var bs [FnSizeMarshalled]byte
+		var before, after Fn
+		for i := 0; i < trials; i++ {
+			before = RandomFn()
+
+			_, _, err := before.Marshal(bs[:], before.SizeHint())
+			Expect(err).ToNot(HaveOccurred())
+
+			tail, rem, err := after.UnmarshalStrict(bs[:], FnSize)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rem).To(Equal(0))
+			Expect(len(tail)).To(Equal(0))
+			Expect(after.Eq(&before)).To(BeTrue())
+		}
+	})
+
+	It("should decode strictly with surge when converted to StrictFn", func() {
+		var x Fn
+		for i := 0; i < trials; i++ {
+			err := surge.FromBinary((*StrictFn)(&x), randomOutOfRangeBytes())
+			Expect(err).To(BeAssignableToTypeOf(&NonCanonicalError{}))
+
+			y := RandomFn()
+			bs, err := surge.ToBinary(StrictFn(y))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(surge.FromBinary((*StrictFn)(&x), bs)).To(Succeed())
+			Expect(x.Eq(&y)).To(BeTrue())
+		}
+
+		// Decoding without the conversion still reduces the value.
+		Expect(surge.FromBinary(&x, randomOutOfRangeBytes())).To(Succeed())
+	})
+
+	It("should panic when strictly setting bytes when the slice length is too small", func() {
+		var x Fn
+		for i := 0; i < 32; i++ {
+			bs := make([]byte, i)
+			Expect(func() { x.SetB32Canonical(bs) }).To(Panic())
+		}
+	})
+
+	It("should be equal after marshaling and unmarshaling in binary", func() {
+		var before, after Fn
+		var bs [32]byte
+		for i := 0; i < trials; i++ {
+			before = RandomFn()
+			data, err := before.MarshalBinary()
+			Expect(err).ToNot(HaveOccurred())
+			before.PutB32(bs[:])
+			Expect(data).To(Equal(bs[:]))
+
+			Expect(after.UnmarshalBinary(data)).To(Succeed())
+			Expect(after.Eq(&before)).To(BeTrue())
+		}
+	})
+
+	It("should be equal after marshaling and unmarshaling as text", func() {
+		var before, after Fn
+		var bs [32]byte
+		for i := 0; i < trials; i++ {
+			before = RandomFn()
+			text, err := before.MarshalText()
+			Expect(err).ToNot(HaveOccurred())
+			before.PutB32(bs[:])
+			Expect(string(text)).To(Equal(hex.EncodeToString(bs[:])))
+
+			Expect(after.UnmarshalText(text)).To(Succeed())
+			Expect(after.Eq(&before)).To(BeTrue())
+		}
+	})
+
+	It("should be equal after marshaling and unmarshaling as JSON", func() {
+		type wrapper struct {
+			Value Fn  `json:"value"`
+			Ptr   *Fn `json:"ptr"`
+		}
+
+		for i := 0; i < trials; i++ {
+			x, y := RandomFn(), RandomFn()
+			before := wrapper{Value: x, Ptr: &y}
+
+			data, err := json.Marshal(before)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(ContainSubstring(`"value":"`))
+
+			var after wrapper
+			Expect(json.Unmarshal(data, &after)).To(Succeed())
+			Expect(after.Value.Eq(&before.Value)).To(BeTrue())
+			Expect(after.Ptr.Eq(before.Ptr)).To(BeTrue())
+		}
+	})
+
+	It("should return an error when unmarshaling bytes not less than N", func() {
+		var x Fn
+		for i := 0; i < trials; i++ {
+			bs := randomOutOfRangeBytes()
+			Expect(x.UnmarshalBinary(bs)).To(BeAssignableToTypeOf(&NonCanonicalError{}))
+			Expect(x.UnmarshalText([]byte(hex.EncodeToString(bs)))).To(BeAssignableToTypeOf(&NonCanonicalError{}))
+			Expect(x.UnmarshalJSON([]byte(`"` + hex.EncodeToString(bs) + `"`))).To(BeAssignableToTypeOf(&NonCanonicalError{}))
+		}
+	})
+
+	It("should return an error when unmarshaling malformed data", func() {
+		var x Fn
+		bs := make([]byte, 33)
+		for i := 0; i < len(bs); i++ {
+			if i != 32 {
+				Expect(x.UnmarshalBinary(bs[:i])).ToNot(Succeed())
+				Expect(x.UnmarshalText([]byte(hex.EncodeToString(bs[:i])))).ToNot(Succeed())
+			}
+		}
+
+		text := []byte(strings.Repeat("0", 63) + "g")
+		Expect(x.UnmarshalText(text)).ToNot(Succeed())
+		Expect(x.UnmarshalText(text[1:])).ToNot(Succeed())
+		Expect(x.UnmarshalJSON([]byte(strings.Repeat("0", 64)))).ToNot(Succeed())
+		Expect(x.UnmarshalJSON([]byte("1"))).ToNot(Succeed())
+	})
+
+	It("should treat JSON null as a no-op when unmarshaling", func() {
+		type wrapper struct {
+			Value Fn  `json:"value"`
+			Ptr   *Fn `json:"ptr"`
+		}
+
+		for i := 0; i < trials/10; i++ {
+			before := RandomFn()
+			x := before
+			Expect(x.UnmarshalJSON([]byte("null"))).To(Succeed())
+			Expect(x.Eq(&before)).To(BeTrue())
+
+			decoded := wrapper{Value: before}
+			Expect(json.Unmarshal([]byte(`{"value": null, "ptr": null}`), &decoded)).To(Succeed())
+			Expect(decoded.Value.Eq(&before)).To(BeTrue())
+			Expect(decoded.Ptr).To(BeNil())
+		}
+	})
+
 	//
 	// Miscellaneous
 	//
 
+	It("should format field elements correctly", func() {
+		var bs [32]byte
+		for i := 0; i < trials; i++ {
+			x := RandomFn()
+			x.PutB32(bs[:])
+			h := hex.EncodeToString(bs[:])
+
+			Expect(x.String()).To(Equal(h))
+			Expect(fmt.Sprintf("%v", x)).To(Equal(h))
+			Expect(fmt.Sprintf("%s", &x)).To(Equal(h))
+			Expect(fmt.Sprintf("%x", x)).To(Equal(h))
+			Expect(fmt.Sprintf("%#x", x)).To(Equal("0x" + h))
+			Expect(fmt.Sprintf("%X", x)).To(Equal(strings.ToUpper(h)))
+			Expect(fmt.Sprintf("%#X", x)).To(Equal("0X" + strings.ToUpper(h)))
+			Expect(fmt.Sprintf("%d", x)).To(Equal(x.Int().String()))
+		}
+
+		x := NewFnFromU16(255)
+		Expect(fmt.Sprintf("%d", x)).To(Equal("255"))
+		Expect(fmt.Sprintf("%6d|%-6d|", x, x)).To(Equal("   255|255   |"))
+		Expect(fmt.Sprintf("%x", x)).To(Equal(strings.Repeat("0", 62) + "ff"))
+		Expect(fmt.Sprintf("%+v", struct{ X Fn }{x})).To(Equal("{X:" + strings.Repeat("0", 62) + "ff}"))
+		Expect(fmt.Sprintf("%q", x)).To(HavePrefix("%!q(secp256k1.Fn="))
+	})
+
 	It("should be zero after clearing", func() {
 		for i := 0; i < trials; i++ {
 			x := RandomFn()
@@ -746,6 +1340,33 @@ var _ = Describe("Fn", func() {
 			Expect(func() { RandomFn() }).To(Panic())
 		})
 	})
+
+	It("should generate the same random field elements from readers with the same seed", func() {
+		r1, r2 := secp256k1tutil.NewSeededReader(42), secp256k1tutil.NewSeededReader(42)
+		for i := 0; i < trials; i++ {
+			x, err := RandomFnFrom(r1)
+			Expect(err).ToNot(HaveOccurred())
+			y, err := RandomFnFrom(r2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(x.Eq(&y)).To(BeTrue())
+		}
+	})
+
+	It("should generate different random field elements from readers with different seeds", func() {
+		r1, r2 := secp256k1tutil.NewSeededReader(1), secp256k1tutil.NewSeededReader(2)
+		for i := 0; i < trials; i++ {
+			x, err := RandomFnFrom(r1)
+			Expect(err).ToNot(HaveOccurred())
+			y, err := RandomFnFrom(r2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(x.Eq(&y)).To(BeFalse())
+		}
+	})
+
+	It("should return an error when the given reader runs out of bytes", func() {
+		_, err := RandomFnFrom(bytes.NewReader(make([]byte, 16)))
+		Expect(err).To(HaveOccurred())
+	})
 })
 
 func BenchmarkFnAdd(b *testing.B) {
@@ -777,3 +1398,56 @@ func BenchmarkFnInverse(b *testing.B) {
 		z.InverseUnsafe(&x)
 	}
 }
+
+func BenchmarkFnBatchInverse100(b *testing.B) {
+	xs := make([]Fn, 100)
+	for i := range xs {
+		xs[i] = RandomFn()
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		BatchInverseFn(xs)
+	}
+}
+
+func BenchmarkFnSqrt(b *testing.B) {
+	var x, z Fn
+
+	x = RandomFn()
+	x.Sqr(&x)
+
+	for i := 0; i < b.N; i++ {
+		z.SqrtUnsafe(&x)
+	}
+}
+
+func BenchmarkFnExp(b *testing.B) {
+	x, e := RandomFn(), RandomFn()
+	var z Fn
+
+	for i := 0; i < b.N; i++ {
+		z.ExpUnsafe(&x, &e)
+	}
+}
+
+func BenchmarkFnExpVar(b *testing.B) {
+	x, e := RandomFn(), RandomFn()
+	var z Fn
+
+	for i := 0; i < b.N; i++ {
+		z.ExpVarUnsafe(&x, &e)
+	}
+}
+
+func BenchmarkFnSetB64(b *testing.B) {
+	var bs [64]byte
+	if _, err := rand.Read(bs[:]); err != nil {
+		panic(err)
+	}
+	var x Fn
+
+	for i := 0; i < b.N; i++ {
+		x.SetB64(bs[:])
+	}
+}
@@ -0,0 +1,51 @@
+package secp256k1
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+)
+
+// formatField implements fmt.Formatter for a field element with the given big
+// endian byte representation. The %x and %X verbs print the bytes in hex, with
+// a 0x prefix if the # flag is given, %d prints the value in decimal, and %v
+// and %s print the same as %x.
+func formatField(f fmt.State, verb rune, v interface{}, bs []byte) {
+	switch verb {
+	case 'v', 's', 'x', 'X':
+		s := hex.EncodeToString(bs)
+		prefix := "0x"
+		if verb == 'X' {
+			s = strings.ToUpper(s)
+			prefix = "0X"
+		}
+		if f.Flag('#') {
+			s = prefix + s
+		}
+		writePadded(f, s)
+	case 'd':
+		writePadded(f, new(big.Int).SetBytes(bs).String())
+	default:
+		fmt.Fprintf(f, "%%!%c(%T=%x)", verb, v, bs)
+	}
+}
+
+// writePadded writes the given string, padded with spaces to the width of the
+// given state if one is set.
+func writePadded(f fmt.State, s string) {
+	width, ok := f.Width()
+	n := utf8.RuneCountInString(s)
+	if !ok || n >= width {
+		fmt.Fprint(f, s)
+		return
+	}
+
+	padding := strings.Repeat(" ", width-n)
+	if f.Flag('-') {
+		fmt.Fprint(f, s, padding)
+	} else {
+		fmt.Fprint(f, padding, s)
+	}
+}
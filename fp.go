@@ -26,11 +26,92 @@ package secp256k1
 // do not call.
 #include "secp256k1/src/num_impl.h"
 
+// Sets r to a raised to the power of the len byte big endian number e. The
+// result is normalized. This function is constant time with respect to the
+// values of a and e.
+void fe_exp(secp256k1_fe *r, const secp256k1_fe *a, const unsigned char *e, size_t len) {
+	secp256k1_fe acc, tmp, base;
+	size_t i;
+	int j;
+
+	base = *a;
+	secp256k1_fe_set_int(&acc, 1);
+	for (i = 0; i < len; i++) {
+		for (j = 7; j >= 0; j--) {
+			secp256k1_fe_sqr(&acc, &acc);
+			secp256k1_fe_mul(&tmp, &acc, &base);
+			secp256k1_fe_cmov(&acc, &tmp, (e[i] >> j) & 1);
+		}
+	}
+	secp256k1_fe_normalize(&acc);
+	*r = acc;
+}
+
+// Sets r to a raised to the power of the len byte big endian number e. The
+// result is normalized. This function is not constant time.
+void fe_exp_var(secp256k1_fe *r, const secp256k1_fe *a, const unsigned char *e, size_t len) {
+	secp256k1_fe acc, base;
+	size_t i;
+	int j, started = 0;
+
+	base = *a;
+	secp256k1_fe_set_int(&acc, 1);
+	for (i = 0; i < len; i++) {
+		for (j = 7; j >= 0; j--) {
+			if (started) {
+				secp256k1_fe_sqr(&acc, &acc);
+			}
+			if ((e[i] >> j) & 1) {
+				secp256k1_fe_mul(&acc, &acc, &base);
+				started = 1;
+			}
+		}
+	}
+	secp256k1_fe_normalize_var(&acc);
+	*r = acc;
+}
+
+// Replaces each of the n normalized field elements by its normalized inverse
+// using Montgomery's trick, which requires a single inversion. Zero field
+// elements are left as zero. The prods array is used as scratch space and must
+// have room for n field elements. This function is not constant time.
+void fe_batch_inverse_var(secp256k1_fe *xs, secp256k1_fe *prods, size_t n) {
+	secp256k1_fe acc, inv, tmp;
+	size_t i;
+
+	if (n == 0) {
+		return;
+	}
+
+	// prods[i] is the product of the non zero field elements before the ith
+	// one.
+	secp256k1_fe_set_int(&acc, 1);
+	for (i = 0; i < n; i++) {
+		prods[i] = acc;
+		if (!secp256k1_fe_is_zero(&xs[i])) {
+			secp256k1_fe_mul(&acc, &acc, &xs[i]);
+		}
+	}
+
+	secp256k1_fe_inv_var(&inv, &acc);
+
+	for (i = n; i-- > 0;) {
+		if (secp256k1_fe_is_zero(&xs[i])) {
+			continue;
+		}
+		secp256k1_fe_mul(&tmp, &inv, &prods[i]);
+		secp256k1_fe_mul(&inv, &inv, &xs[i]);
+		xs[i] = tmp;
+		secp256k1_fe_normalize_var(&xs[i]);
+	}
+}
+
 */
 import "C"
 import (
 	"crypto/rand"
 	"fmt"
+	"io"
 	"math/big"
 	"unsafe"
 
@@ -44,6 +125,9 @@ const FpSize int = int(unsafe.Sizeof(Fp{}))
 // curve point.
 const FpSizeMarshalled int = 32
 
+// The prime of the field over which the elliptic curve secp256k1 is defined.
+var pInt, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F", 16)
+
 // Fp represents an element of the field corresponding to the coordinates of
 // the points that lie on the secp256k1 elliptic curve.
 type Fp struct {
@@ -73,6 +157,23 @@ func (x *Fp) SetU64(v uint64) {
 	x.inner.n[4] = 0
 }
 
+// SetInt sets the field element to be equal to the given integer reduced
+// modulo P. Negative integers are reduced to their non negative
+// representative, so for example -1 is mapped to P - 1.
+func (x *Fp) SetInt(v *big.Int) {
+	if v == nil {
+		panic("expected first argument to be not be nil")
+	}
+
+	if v.Sign() < 0 || v.Cmp(pInt) >= 0 {
+		v = new(big.Int).Mod(v, pInt)
+	}
+
+	var bs [32]byte
+	v.FillBytes(bs[:])
+	x.SetB32(bs[:])
+}
+
 // RandomFp returns a random Fp field element.
 //
 // Panics: This function will panic if there was an error reading bytes from
@@ -88,18 +189,28 @@ func RandomFp() Fp {
 // RandomFpNoPanic returns a random Fp field element or an error if it could
 // not read from the random source.
 func RandomFpNoPanic() (Fp, error) {
+	return RandomFpFrom(rand.Reader)
+}
+
+// RandomFpFrom returns a random Fp field element using the given source of
+// randomness, or an error if it could not read from the source. The result is
+// uniform if the source is, and deterministic if the source is.
+func RandomFpFrom(r io.Reader) (Fp, error) {
 	var bs [32]byte
-	_, err := rand.Read(bs[:])
-	if err != nil {
-		return Fp{}, err
-	}
 	x := Fp{}
 
-	// This will reduce the value modulo P, so it does not matter if the bytes
-	// represent a number greater than P.
-	x.SetB32(bs[:])
-
-	return x, nil
+	// Values greater than or equal to P are rejected rather than reduced, so
+	// that the result is uniform. This happens with probability roughly
+	// 2^-224, so in practice only one read is ever needed.
+	for {
+		_, err := io.ReadFull(r, bs[:])
+		if err != nil {
+			return Fp{}, err
+		}
+		if !x.SetB32(bs[:]) {
+			return x, nil
+		}
+	}
 }
 
 // Clear sets the underlying data of the structure to zero. This will leave it
@@ -204,6 +315,26 @@ func (x *Fp) SetB32(bs []byte) bool {
 	return greater
 }
 
+// SetB32Canonical sets the field element to be equal to the given byte slice,
+// interpreted as big endian. Unlike SetB32, the value is not reduced: if the
+// bytes represent a number greater than or equal to P, a *NonCanonicalError
+// is returned and the receiver is left unchanged.
+//
+// Panics: If the byte slice has length less than 32, this function will panic.
+func (x *Fp) SetB32Canonical(bs []byte) error {
+	if len(bs) < 32 {
+		panic(fmt.Sprintf("invalid slice length: length needs to be at least 32, got %v", len(bs)))
+	}
+
+	var tmp C.secp256k1_fe
+	if !set5x52FromB32(bs, &tmp) {
+		return &NonCanonicalError{Type: "Fp"}
+	}
+
+	x.inner = tmp
+	return nil
+}
+
 func set5x52FromB32(bs []byte, dst *C.secp256k1_fe) bool {
 	dst.n[0] = C.uint64_t(bs[31]) |
 		(C.uint64_t(bs[30]) << 8) |
@@ -266,7 +397,9 @@ func (x Fp) Marshal(buf []byte, rem int) ([]byte, int, error) {
 	return buf[FpSizeMarshalled:], rem - FpSizeMarshalled, nil
 }
 
-// Unmarshal implements the surge.Unmarshaler interface.
+// Unmarshal implements the surge.Unmarshaler interface. Bytes that represent a
+// number greater than or equal to P are reduced modulo P. Use
+// UnmarshalStrict or StrictFp to reject them instead.
 func (x *Fp) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	if len(buf) < FpSizeMarshalled || rem < FpSize {
 		return buf, rem, surge.ErrUnexpectedEndOfBuffer
@@ -277,6 +410,80 @@ func (x *Fp) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	return buf[FpSizeMarshalled:], rem - FpSize, nil
 }
 
+// UnmarshalStrict is the same as Unmarshal, except that it returns a
+// *NonCanonicalError if the bytes represent a number greater than or equal to
+// P. In this case the receiver is left unchanged. To select strict decoding
+// for a field element nested in another type, use StrictFp.
+func (x *Fp) UnmarshalStrict(buf []byte, rem int) ([]byte, int, error) {
+	if len(buf) < FpSizeMarshalled || rem < FpSize {
+		return buf, rem, surge.ErrUnexpectedEndOfBuffer
+	}
+
+	if err := x.SetB32Canonical(buf[:FpSizeMarshalled]); err != nil {
+		return buf, rem, err
+	}
+
+	return buf[FpSizeMarshalled:], rem - FpSize, nil
+}
+
+// String implements the fmt.Stringer interface. The field element is printed
+// as the hex string of its 32 byte big endian representation.
+func (x Fp) String() string {
+	return fmt.Sprint(x)
+}
+
+// Format implements the fmt.Formatter interface. The %x and %X verbs print the
+// 32 byte big endian representation of the field element in hex, with a 0x
+// prefix if the # flag is given, %d prints the field element in decimal, and
+// %v and %s print the same as %x. A width can be given to pad the result.
+func (x Fp) Format(f fmt.State, verb rune) {
+	var bs [32]byte
+	x.PutB32(bs[:])
+	formatField(f, verb, x, bs[:])
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface. The field
+// element is encoded as 32 big endian bytes.
+func (x Fp) MarshalBinary() ([]byte, error) {
+	bs := make([]byte, FpSizeMarshalled)
+	x.PutB32(bs)
+	return bs, nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface. An
+// error is returned if the data does not have length 32, or if it represents a
+// number greater than or equal to P.
+func (x *Fp) UnmarshalBinary(data []byte) error {
+	if len(data) != FpSizeMarshalled {
+		return fmt.Errorf("invalid length: expected %v bytes, got %v", FpSizeMarshalled, len(data))
+	}
+	return x.SetB32Canonical(data)
+}
+
+// MarshalText implements the encoding.TextMarshaler interface. The field
+// element is encoded as the hex string of its binary representation.
+func (x Fp) MarshalText() ([]byte, error) {
+	return marshalHexText(x)
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface, with the
+// same checks as UnmarshalBinary.
+func (x *Fp) UnmarshalText(text []byte) error {
+	return unmarshalHexText(x, text)
+}
+
+// MarshalJSON implements the json.Marshaler interface. The field element is
+// encoded as a JSON string containing its text representation.
+func (x Fp) MarshalJSON() ([]byte, error) {
+	return marshalJSONText(x)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, with the same
+// checks as UnmarshalBinary.
+func (x *Fp) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(x, data)
+}
+
 // Add computes the addition of the two field elements and stores the result in
 // the receiver.
 //
@@ -441,6 +648,108 @@ func (x *Fp) SqrUnsafe(a *Fp) {
 	x.normalize()
 }
 
+// Exp computes the given field element raised to the power of the given
+// exponent, interpreted as a big endian number, and stores the result in the
+// receiver. The computation uses a time invariant algorithm, so it is suitable
+// for secret exponents; the running time depends only on the length of the
+// exponent.
+func (x *Fp) Exp(a *Fp, e []byte) {
+	if a == nil {
+		panic("expected first argument to be not be nil")
+	}
+
+	x.ExpUnsafe(a, e)
+}
+
+// ExpUnsafe computes the given field element raised to the power of the given
+// exponent, interpreted as a big endian number, and stores the result in the
+// receiver. The computation uses a time invariant algorithm, so it is suitable
+// for secret exponents; the running time depends only on the length of the
+// exponent.
+//
+// Unsafe: If this function receives nil arguments, the behaviour is
+// implementation dependent, because the definition of the NULL pointer in c is
+// implementation dependent. If the NULL pointer and the go nil pointer are the
+// same, then the function will panic.
+func (x *Fp) ExpUnsafe(a *Fp, e []byte) {
+	if len(e) == 0 {
+		x.SetU64(1)
+		return
+	}
+
+	C.fe_exp(&x.inner, &a.inner, (*C.uchar)(&e[0]), C.size_t(len(e)))
+}
+
+// ExpVar computes the given field element raised to the power of the given
+// exponent, interpreted as a big endian number, and stores the result in the
+// receiver. The computation is not time invariant, so it should only be used
+// when the exponent and base are public.
+func (x *Fp) ExpVar(a *Fp, e []byte) {
+	if a == nil {
+		panic("expected first argument to be not be nil")
+	}
+
+	x.ExpVarUnsafe(a, e)
+}
+
+// ExpVarUnsafe computes the given field element raised to the power of the
+// given exponent, interpreted as a big endian number, and stores the result in
+// the receiver. The computation is not time invariant, so it should only be
+// used when the exponent and base are public.
+//
+// Unsafe: If this function receives nil arguments, the behaviour is
+// implementation dependent, because the definition of the NULL pointer in c is
+// implementation dependent. If the NULL pointer and the go nil pointer are the
+// same, then the function will panic.
+func (x *Fp) ExpVarUnsafe(a *Fp, e []byte) {
+	if len(e) == 0 {
+		x.SetU64(1)
+		return
+	}
+
+	C.fe_exp_var(&x.inner, &a.inner, (*C.uchar)(&e[0]), C.size_t(len(e)))
+}
+
+// Sqrt computes a square root of the given field element and stores the result
+// in the receiver, and returns true if the field element is a square. If it is
+// not a square, false is returned and the receiver is left unchanged. Of the
+// two square roots, the one that is itself a square is chosen.
+func (x *Fp) Sqrt(a *Fp) bool {
+	if a == nil {
+		panic("expected first argument to be not be nil")
+	}
+
+	return x.SqrtUnsafe(a)
+}
+
+// SqrtUnsafe computes a square root of the given field element and stores the
+// result in the receiver, and returns true if the field element is a square.
+// If it is not a square, false is returned and the receiver is left unchanged.
+// Of the two square roots, the one that is itself a square is chosen.
+//
+// Unsafe: If this function receives nil arguments, the behaviour is
+// implementation dependent, because the definition of the NULL pointer in c is
+// implementation dependent. If the NULL pointer and the go nil pointer are the
+// same, then the function will panic.
+func (x *Fp) SqrtUnsafe(a *Fp) bool {
+	// The c function does not allow the arguments to alias each other.
+	var root C.secp256k1_fe
+	if C.secp256k1_fe_sqrt(&root, &a.inner) == 0 {
+		return false
+	}
+
+	x.inner = root
+	x.normalize()
+	return true
+}
+
+// IsSquare returns true if the field element is a square, that is if it has a
+// square root, and false otherwise. Zero is a square.
+func (x *Fp) IsSquare() bool {
+	var root C.secp256k1_fe
+	return C.secp256k1_fe_sqrt(&root, &x.inner) != 0
+}
+
 // Inv computes the multiplicative inverse of the field element and stores the
 // result in the receiver.
 func (x *Fp) Inv(a *Fp) {
@@ -465,6 +774,20 @@ func (x *Fp) InvUnsafe(a *Fp) {
 	x.normalize()
 }
 
+// BatchInverseFp replaces each of the given field elements by its
+// multiplicative inverse. This is significantly faster than inverting each of
+// the field elements individually, as only a single inversion is computed.
+// Field elements that are zero are left unchanged, which is consistent with
+// Inv.
+func BatchInverseFp(xs []Fp) {
+	if len(xs) == 0 {
+		return
+	}
+
+	prods := make([]Fp, len(xs))
+	C.fe_batch_inverse_var(&xs[0].inner, &prods[0].inner, C.size_t(len(xs)))
+}
+
 // IsZero returns true if the field element is zero and false otherwise.
 func (x *Fp) IsZero() bool {
 	return (x.inner.n[0] | x.inner.n[1] | x.inner.n[2] | x.inner.n[3] | x.inner.n[4]) == 0
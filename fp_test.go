@@ -1,13 +1,20 @@
 package secp256k1_test
 
 import (
+	"bytes"
 	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"math/big"
+	"strings"
+	"testing"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/renproject/secp256k1"
 	"github.com/renproject/secp256k1/secp256k1tutil"
+	"github.com/renproject/surge"
 )
 
 var _ = Describe("Fp", func() {
@@ -149,6 +156,183 @@ var _ = Describe("Fp", func() {
 		}
 	})
 
+	It("should compute square roots correctly", func() {
+		var a, root, squared Fp
+		x := new(big.Int)
+		for i := 0; i < trials; i++ {
+			a = RandomFp()
+			a.PutInt(x)
+			expected := big.Jacobi(x, P) >= 0
+
+			root = Fp{}
+			Expect(root.Sqrt(&a)).To(Equal(expected))
+			if expected {
+				squared.Sqr(&root)
+				Expect(squared.Eq(&a)).To(BeTrue())
+				Expect(root.IsSquare()).To(BeTrue())
+				Expect(new(big.Int).ModSqrt(x, P)).ToNot(BeNil())
+			} else {
+				// The receiver is left unchanged.
+				Expect(root.IsZero()).To(BeTrue())
+				Expect(new(big.Int).ModSqrt(x, P)).To(BeNil())
+			}
+		}
+	})
+
+	It("should compute the square root of a square", func() {
+		var a, b, root, negated Fp
+		for i := 0; i < trials; i++ {
+			a = RandomFp()
+			b.Sqr(&a)
+
+			Expect(b.IsSquare()).To(BeTrue())
+			Expect(root.Sqrt(&b)).To(BeTrue())
+
+			negated.Negate(&a)
+			Expect(root.Eq(&a) || root.Eq(&negated)).To(BeTrue())
+		}
+	})
+
+	It("should identify squares", func() {
+		var a Fp
+		x := new(big.Int)
+		for i := 0; i < trials; i++ {
+			a = RandomFp()
+			a.PutInt(x)
+			Expect(a.IsSquare()).To(Equal(big.Jacobi(x, P) >= 0))
+		}
+
+		zero := Fp{}
+		Expect(zero.IsSquare()).To(BeTrue())
+
+		// -1 is not a square because P = 3 mod 4.
+		one := NewFpFromU64(1)
+		var minusOne Fp
+		minusOne.Negate(&one)
+		Expect(minusOne.IsSquare()).To(BeFalse())
+	})
+
+	It("should compute square roots correctly when the argument is an alias of the receiver", func() {
+		var a, b, expected Fp
+		for i := 0; i < trials; i++ {
+			a = RandomFp()
+			b = a
+			ok := expected.Sqrt(&a)
+			Expect(b.Sqrt(&b)).To(Equal(ok))
+			if ok {
+				Expect(b.Eq(&expected)).To(BeTrue())
+			} else {
+				Expect(b.Eq(&a)).To(BeTrue())
+			}
+		}
+	})
+
+	It("should panic when computing a square root when the argument is nil", func() {
+		var x Fp
+		Expect(func() { x.Sqrt(nil) }).To(Panic())
+	})
+
+	Specify("square roots should be the same as the unsafe variant", func() {
+		var x, safe, unsafe Fp
+		for i := 0; i < trials; i++ {
+			x = RandomFp()
+
+			Expect(safe.Sqrt(&x)).To(Equal(unsafe.SqrtUnsafe(&x)))
+			Expect(safe.Eq(&unsafe)).To(BeTrue())
+		}
+	})
+
+	It("should batch invert correctly", func() {
+		for _, n := range []int{0, 1, 2, 3, 10, 100} {
+			xs := make([]Fp, n)
+			expected := make([]Fp, n)
+			for i := range xs {
+				xs[i] = RandomFp()
+				expected[i].InvUnsafe(&xs[i])
+			}
+
+			BatchInverseFp(xs)
+			for i := range xs {
+				Expect(xs[i].Eq(&expected[i])).To(BeTrue())
+			}
+		}
+	})
+
+	It("should leave zero elements unchanged when batch inverting", func() {
+		for i := 0; i < trials/10; i++ {
+			xs := make([]Fp, 10)
+			expected := make([]Fp, 10)
+			for j := range xs {
+				if (i+j)%3 != 0 {
+					xs[j] = RandomFp()
+				}
+				expected[j].InvUnsafe(&xs[j])
+			}
+
+			BatchInverseFp(xs)
+			for j := range xs {
+				Expect(xs[j].Eq(&expected[j])).To(BeTrue())
+				if (i+j)%3 == 0 {
+					Expect(xs[j].IsZero()).To(BeTrue())
+				}
+			}
+		}
+
+		xs := make([]Fp, 5)
+		BatchInverseFp(xs)
+		for j := range xs {
+			Expect(xs[j].IsZero()).To(BeTrue())
+		}
+	})
+
+	It("should exponentiate correctly", func() {
+		var a, b Fp
+		x, y, expected, actual := new(big.Int), new(big.Int), new(big.Int), new(big.Int)
+		for i := 0; i < trials/10; i++ {
+			// Exponents of various lengths, including lengths greater than 32
+			// bytes and the empty exponent.
+			e := make([]byte, i%70)
+			if _, err := rand.Read(e); err != nil {
+				panic(err)
+			}
+
+			a = RandomFp()
+			a.PutInt(x)
+			y.SetBytes(e)
+			expected.Exp(x, y, P)
+
+			b.ExpUnsafe(&a, e)
+			b.PutInt(actual)
+			Expect(actual.Cmp(expected)).To(Equal(0))
+
+			b.ExpVarUnsafe(&a, e)
+			b.PutInt(actual)
+			Expect(actual.Cmp(expected)).To(Equal(0))
+		}
+	})
+
+	It("should exponentiate correctly when the base is zero", func() {
+		var b Fp
+		e := make([]byte, 32)
+		for i := 0; i < trials/10; i++ {
+			if _, err := rand.Read(e); err != nil {
+				panic(err)
+			}
+			e[31] |= 1
+
+			b.Exp(&zero, e)
+			Expect(b.IsZero()).To(BeTrue())
+
+			b.ExpVar(&zero, e)
+			Expect(b.IsZero()).To(BeTrue())
+		}
+
+		b.Exp(&zero, nil)
+		Expect(b.IsOne()).To(BeTrue())
+		b.ExpVar(&zero, []byte{0, 0})
+		Expect(b.IsOne()).To(BeTrue())
+	})
+
 	//
 	// Properties
 	//
@@ -367,6 +551,12 @@ var _ = Describe("Fp", func() {
 		Expect(func() { x.Inv(nil) }).To(Panic())
 	})
 
+	It("should panic when exponentiating when the argument is nil", func() {
+		var x Fp
+		Expect(func() { x.Exp(nil, []byte{1}) }).To(Panic())
+		Expect(func() { x.ExpVar(nil, []byte{1}) }).To(Panic())
+	})
+
 	It("should panic when negating when the argument is nil", func() {
 		var x Fp
 		Expect(func() { x.Negate(nil) }).To(Panic())
@@ -467,6 +657,35 @@ var _ = Describe("Fp", func() {
 		}
 	})
 
+	It("should be one after raising to the power of P - 1", func() {
+		var a, b Fp
+		e := new(big.Int).Sub(P, big.NewInt(1)).Bytes()
+		for i := 0; i < trials/10; i++ {
+			a = RandomFp()
+			if a.IsZero() {
+				continue
+			}
+
+			b.Exp(&a, e)
+			Expect(b.IsOne()).To(BeTrue())
+		}
+	})
+
+	It("should be equal after exponentiating when the base is an alias of the receiver", func() {
+		var a, expected Fp
+		e := make([]byte, 32)
+		for i := 0; i < trials/10; i++ {
+			if _, err := rand.Read(e); err != nil {
+				panic(err)
+			}
+			a = RandomFp()
+			expected.Exp(&a, e)
+
+			a.Exp(&a, e)
+			Expect(a.Eq(&expected)).To(BeTrue())
+		}
+	})
+
 	It("should be one after multiplying by the multiplicative inverse", func() {
 		var x, y Fp
 		for i := 0; i < trials; i++ {
@@ -558,6 +777,58 @@ var _ = Describe("Fp", func() {
 		}
 	})
 
+	It("should be equal to the given integer reduced modulo P", func() {
+		var x Fp
+		bound := new(big.Int).Lsh(big.NewInt(1), 512)
+		expected, actual := new(big.Int), new(big.Int)
+		for i := 0; i < trials; i++ {
+			v, err := rand.Int(rand.Reader, bound)
+			if err != nil {
+				panic(err)
+			}
+			if i%2 == 0 {
+				v.Neg(v)
+			}
+			expected.Mod(v, P)
+
+			x.SetInt(v)
+			x.PutInt(actual)
+			Expect(actual.Cmp(expected)).To(Equal(0))
+		}
+	})
+
+	It("should be unchanged after converting to and from a big.Int", func() {
+		var x, y Fp
+		for i := 0; i < trials; i++ {
+			x = RandomFp()
+			y.SetInt(x.Int())
+			Expect(y.Eq(&x)).To(BeTrue())
+		}
+	})
+
+	It("should reduce integers at the boundary of the field correctly", func() {
+		var x Fp
+
+		x.SetInt(P)
+		Expect(x.IsZero()).To(BeTrue())
+
+		x.SetInt(big.NewInt(-1))
+		var minusOne Fp
+		minusOne.Negate(&one)
+		Expect(x.Eq(&minusOne)).To(BeTrue())
+
+		x.SetInt(new(big.Int).Add(P, big.NewInt(1)))
+		Expect(x.Eq(&one)).To(BeTrue())
+
+		x.SetInt(new(big.Int).Neg(P))
+		Expect(x.IsZero()).To(BeTrue())
+	})
+
+	It("should panic when setting from a nil big.Int", func() {
+		var x Fp
+		Expect(func() { x.SetInt(nil) }).To(Panic())
+	})
+
 	It("should be equal after marshaling and unmarshaling with surge", func() {
 		var bs [FpSizeMarshalled]byte
 		var before, after Fp
@@ -640,10 +911,223 @@ var _ = Describe("Fp", func() {
 		}
 	})
 
+	It("should set canonical bytes strictly", func() {
+		var bs [32]byte
+		var x, y Fp
+		for i := 0; i < trials; i++ {
+			x = RandomFp()
+			x.PutB32(bs[:])
+			Expect(y.SetB32Canonical(bs[:])).To(Succeed())
+			Expect(y.Eq(&x)).To(BeTrue())
+		}
+	})
+
+	It("should return an error when strictly setting bytes not less than P", func() {
+		var x, before Fp
+		for i := 0; i < trials; i++ {
+			x = RandomFp()
+			before = x
+
+			bs := randomOutOfRangeBytes()
+			err := x.SetB32Canonical(bs)
+			Expect(err).To(BeAssignableToTypeOf(&NonCanonicalError{}))
+
+			// The receiver is left unchanged.
+			Expect(x.Eq(&before)).To(BeTrue())
+		}
+
+		bs := make([]byte, 32)
+		P.FillBytes(bs)
+		Expect(x.SetB32Canonical(bs)).To(BeAssignableToTypeOf(&NonCanonicalError{}))
+	})
+
+	It("should return an error when strictly unmarshalling bytes not less than P", func() {
+		for i := 0; i < trials; i++ {
+			var x Fp
+			bs := randomOutOfRangeBytes()
+
+			tail, rem, err := x.UnmarshalStrict(bs, FpSize)
+			Expect(err).To(BeAssignableToTypeOf(&NonCanonicalError{}))
+			Expect(rem).To(Equal(FpSize))
+			Expect(len(tail)).To(Equal(FpSizeMarshalled))
+			Expect(x.IsZero()).To(BeTrue())
+
+			// The default decoding reduces the value.
+			tail, rem, err = x.Unmarshal(bs, FpSize)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rem).To(Equal(0))
+			Expect(len(tail)).To(Equal(0))
+		}
+	})
+
+	It("should be equal after marshaling and strictly unmarshaling with surge", func() {
+		var bs [FpSizeMarshalled]byte
+		var before, after Fp
+		for i := 0; i < trials; i++ {
+			before = RandomFp()
+
+			_, _, err := before.Marshal(bs[:], before.SizeHint())
+			Expect(err).ToNot(HaveOccurred())
+
+			tail, rem, err := after.UnmarshalStrict(bs[:], FpSize)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rem).To(Equal(0))
+			Expect(len(tail)).To(Equal(0))
+			Expect(after.Eq(&before)).To(BeTrue())
+		}
+	})
+
+	It("should decode strictly with surge when converted to StrictFp", func() {
+		var x Fp
+		for i := 0; i < trials; i++ {
+			err := surge.FromBinary((*StrictFp)(&x), randomOutOfRangeBytes())
+			Expect(err).To(BeAssignableToTypeOf(&NonCanonicalError{}))
+
+			y := RandomFp()
+			bs, err := surge.ToBinary(StrictFp(y))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(surge.FromBinary((*StrictFp)(&x), bs)).To(Succeed())
+			Expect(x.Eq(&y)).To(BeTrue())
+		}
+
+		// Decoding without the conversion still reduces the value.
+		Expect(surge.FromBinary(&x, randomOutOfRangeBytes())).To(Succeed())
+	})
+
+	It("should panic when strictly setting bytes when the slice length is too small", func() {
+		var x Fp
+		for i := 0; i < 32; i++ {
+			bs := make([]byte, i)
+			Expect(func() { x.SetB32Canonical(bs) }).To(Panic())
+		}
+	})
+
+	It("should be equal after marshaling and unmarshaling in binary", func() {
+		var before, after Fp
+		var bs [32]byte
+		for i := 0; i < trials; i++ {
+			before = RandomFp()
+			data, err := before.MarshalBinary()
+			Expect(err).ToNot(HaveOccurred())
+			before.PutB32(bs[:])
+			Expect(data).To(Equal(bs[:]))
+
+			Expect(after.UnmarshalBinary(data)).To(Succeed())
+			Expect(after.Eq(&before)).To(BeTrue())
+		}
+	})
+
+	It("should be equal after marshaling and unmarshaling as text", func() {
+		var before, after Fp
+		var bs [32]byte
+		for i := 0; i < trials; i++ {
+			before = RandomFp()
+			text, err := before.MarshalText()
+			Expect(err).ToNot(HaveOccurred())
+			before.PutB32(bs[:])
+			Expect(string(text)).To(Equal(hex.EncodeToString(bs[:])))
+
+			Expect(after.UnmarshalText(text)).To(Succeed())
+			Expect(after.Eq(&before)).To(BeTrue())
+		}
+	})
+
+	It("should be equal after marshaling and unmarshaling as JSON", func() {
+		type wrapper struct {
+			Value Fp  `json:"value"`
+			Ptr   *Fp `json:"ptr"`
+		}
+
+		for i := 0; i < trials; i++ {
+			x, y := RandomFp(), RandomFp()
+			before := wrapper{Value: x, Ptr: &y}
+
+			data, err := json.Marshal(before)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(ContainSubstring(`"value":"`))
+
+			var after wrapper
+			Expect(json.Unmarshal(data, &after)).To(Succeed())
+			Expect(after.Value.Eq(&before.Value)).To(BeTrue())
+			Expect(after.Ptr.Eq(before.Ptr)).To(BeTrue())
+		}
+	})
+
+	It("should return an error when unmarshaling bytes not less than P", func() {
+		var x Fp
+		for i := 0; i < trials; i++ {
+			bs := randomOutOfRangeBytes()
+			Expect(x.UnmarshalBinary(bs)).To(BeAssignableToTypeOf(&NonCanonicalError{}))
+			Expect(x.UnmarshalText([]byte(hex.EncodeToString(bs)))).To(BeAssignableToTypeOf(&NonCanonicalError{}))
+			Expect(x.UnmarshalJSON([]byte(`"` + hex.EncodeToString(bs) + `"`))).To(BeAssignableToTypeOf(&NonCanonicalError{}))
+		}
+	})
+
+	It("should return an error when unmarshaling malformed data", func() {
+		var x Fp
+		bs := make([]byte, 33)
+		for i := 0; i < len(bs); i++ {
+			if i != 32 {
+				Expect(x.UnmarshalBinary(bs[:i])).ToNot(Succeed())
+				Expect(x.UnmarshalText([]byte(hex.EncodeToString(bs[:i])))).ToNot(Succeed())
+			}
+		}
+
+		text := []byte(strings.Repeat("0", 63) + "g")
+		Expect(x.UnmarshalText(text)).ToNot(Succeed())
+		Expect(x.UnmarshalText(text[1:])).ToNot(Succeed())
+		Expect(x.UnmarshalJSON([]byte(strings.Repeat("0", 64)))).ToNot(Succeed())
+		Expect(x.UnmarshalJSON([]byte("1"))).ToNot(Succeed())
+	})
+
+	It("should treat JSON null as a no-op when unmarshaling", func() {
+		type wrapper struct {
+			Value Fp  `json:"value"`
+			Ptr   *Fp `json:"ptr"`
+		}
+
+		for i := 0; i < trials/10; i++ {
+			before := RandomFp()
+			x := before
+			Expect(x.UnmarshalJSON([]byte("null"))).To(Succeed())
+			Expect(x.Eq(&before)).To(BeTrue())
+
+			decoded := wrapper{Value: before}
+			Expect(json.Unmarshal([]byte(`{"value": null, "ptr": null}`), &decoded)).To(Succeed())
+			Expect(decoded.Value.Eq(&before)).To(BeTrue())
+			Expect(decoded.Ptr).To(BeNil())
+		}
+	})
+
 	//
 	// Miscellaneous
 	//
 
+	It("should format field elements correctly", func() {
+		var bs [32]byte
+		for i := 0; i < trials; i++ {
+			x := RandomFp()
+			x.PutB32(bs[:])
+			h := hex.EncodeToString(bs[:])
+
+			Expect(x.String()).To(Equal(h))
+			Expect(fmt.Sprintf("%v", x)).To(Equal(h))
+			Expect(fmt.Sprintf("%s", &x)).To(Equal(h))
+			Expect(fmt.Sprintf("%x", x)).To(Equal(h))
+			Expect(fmt.Sprintf("%#x", x)).To(Equal("0x" + h))
+			Expect(fmt.Sprintf("%X", x)).To(Equal(strings.ToUpper(h)))
+			Expect(fmt.Sprintf("%#X", x)).To(Equal("0X" + strings.ToUpper(h)))
+			Expect(fmt.Sprintf("%d", x)).To(Equal(x.Int().String()))
+		}
+
+		x := NewFpFromU64(255)
+		Expect(fmt.Sprintf("%d", x)).To(Equal("255"))
+		Expect(fmt.Sprintf("%6d|%-6d|", x, x)).To(Equal("   255|255   |"))
+		Expect(fmt.Sprintf("%x", x)).To(Equal(strings.Repeat("0", 62) + "ff"))
+		Expect(fmt.Sprintf("%+v", struct{ X Fp }{x})).To(Equal("{X:" + strings.Repeat("0", 62) + "ff}"))
+		Expect(fmt.Sprintf("%q", x)).To(HavePrefix("%!q(secp256k1.Fp="))
+	})
+
 	It("should be zero after clearing", func() {
 		for i := 0; i < trials; i++ {
 			x := RandomFp()
@@ -777,6 +1261,20 @@ var _ = Describe("Fp", func() {
 		}
 	})
 
+	It("should reject random bytes that are not less than P", func() {
+		// The first 32 bytes represent a number greater than P, so they should
+		// be discarded.
+		high := bytes.Repeat([]byte{0xFF}, 32)
+		low := bytes.Repeat([]byte{0x01}, 32)
+
+		x, err := RandomFpFrom(bytes.NewReader(append(high, low...)))
+		Expect(err).ToNot(HaveOccurred())
+
+		var expected Fp
+		expected.SetB32(low)
+		Expect(x.Eq(&expected)).To(BeTrue())
+	})
+
 	It("should return an error when there is a read error when safely generating a random field element", func() {
 		secp256k1tutil.UseErrReader(func() {
 			x, err := RandomFpNoPanic()
@@ -790,4 +1288,80 @@ var _ = Describe("Fp", func() {
 			Expect(func() { RandomFp() }).To(Panic())
 		})
 	})
+
+	It("should generate the same random field elements from readers with the same seed", func() {
+		r1, r2 := secp256k1tutil.NewSeededReader(42), secp256k1tutil.NewSeededReader(42)
+		for i := 0; i < trials; i++ {
+			x, err := RandomFpFrom(r1)
+			Expect(err).ToNot(HaveOccurred())
+			y, err := RandomFpFrom(r2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(x.Eq(&y)).To(BeTrue())
+		}
+	})
+
+	It("should generate different random field elements from readers with different seeds", func() {
+		r1, r2 := secp256k1tutil.NewSeededReader(1), secp256k1tutil.NewSeededReader(2)
+		for i := 0; i < trials; i++ {
+			x, err := RandomFpFrom(r1)
+			Expect(err).ToNot(HaveOccurred())
+			y, err := RandomFpFrom(r2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(x.Eq(&y)).To(BeFalse())
+		}
+	})
+
+	It("should return an error when the given reader runs out of bytes", func() {
+		_, err := RandomFpFrom(bytes.NewReader(make([]byte, 16)))
+		Expect(err).To(HaveOccurred())
+	})
 })
+
+func BenchmarkFpBatchInverse100(b *testing.B) {
+	xs := make([]Fp, 100)
+	for i := range xs {
+		xs[i] = RandomFp()
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		BatchInverseFp(xs)
+	}
+}
+
+func BenchmarkFpSqrt(b *testing.B) {
+	var x, z Fp
+
+	x = RandomFp()
+	x.Sqr(&x)
+
+	for i := 0; i < b.N; i++ {
+		z.SqrtUnsafe(&x)
+	}
+}
+
+func BenchmarkFpExp(b *testing.B) {
+	x := RandomFp()
+	e := make([]byte, 32)
+	if _, err := rand.Read(e); err != nil {
+		panic(err)
+	}
+	var z Fp
+
+	for i := 0; i < b.N; i++ {
+		z.ExpUnsafe(&x, e)
+	}
+}
+
+func BenchmarkFpExpVar(b *testing.B) {
+	x := RandomFp()
+	e := make([]byte, 32)
+	if _, err := rand.Read(e); err != nil {
+		panic(err)
+	}
+	var z Fp
+
+	for i := 0; i < b.N; i++ {
+		z.ExpVarUnsafe(&x, e)
+	}
+}
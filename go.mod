@@ -1,6 +1,6 @@
 module github.com/renproject/secp256k1
 
-go 1.14
+go 1.15
 
 require (
 	github.com/dustinxie/ecc v0.0.0-20210511000915-959544187564
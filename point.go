@@ -5,8 +5,10 @@ package secp256k1
 #define USE_NUM_GMP
 #define HAVE___INT128
 
-// TODO: What should this be set to?
-#define ECMULT_WINDOW_SIZE 24
+// NOTE: The constant time scalar multiplication does not use the precomputed
+// ecmult tables, but including its implementation requires the window size
+// to be defined. The tables for the generator are built in ecmult.go.
+#define ECMULT_WINDOW_SIZE 15
 
 #include "secp256k1/include/secp256k1.h"
 
@@ -35,26 +37,24 @@ package secp256k1
 #include "secp256k1/src/ecmult_const.h"
 #include "secp256k1/src/ecmult_const_impl.h"
 
-// NOTE: cgo cannot reference static variables, so we need to redefine the
-// generator as a non static variable. The use of the macro is copied from
-// group_impl.h.
-const secp256k1_ge secp256k1_generator = SECP256K1_GE_CONST(
-	0x79BE667EUL, 0xF9DCBBACUL, 0x55A06295UL, 0xCE870B07UL,
-	0x029BFCDBUL, 0x2DCE28D9UL, 0x59F2815BUL, 0x16F81798UL,
-	0x483ADA77UL, 0x26A3C465UL, 0x5DA4FBFCUL, 0x0E1108A8UL,
-	0xFD17B448UL, 0xA6855419UL, 0x9C47D08FUL, 0xFB10D4B8UL
-);
-
 // The c null pointer, which we define as it may be different from the go nil
 // pointer.
 secp256k1_fe * null_ptr = NULL;
 
+// Sets r to the canonical generator G, which is only available as a static
+// constant.
+void ge_const_g(secp256k1_ge *r) {
+	*r = secp256k1_ge_const_g;
+}
+
 */
 import "C"
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io"
 	"unsafe"
 
 	"github.com/renproject/surge"
@@ -68,6 +68,14 @@ const PointSize int = int(unsafe.Sizeof(Point{}))
 // curve point.
 const PointSizeMarshalled int = 33
 
+// PointSizeSEC1Compressed is the number of bytes needed to represent a curve
+// point, other than the point at infinity, in the SEC1 compressed form.
+const PointSizeSEC1Compressed int = 33
+
+// PointSizeSEC1Uncompressed is the number of bytes needed to represent a curve
+// point, other than the point at infinity, in the SEC1 uncompressed form.
+const PointSizeSEC1Uncompressed int = 65
+
 // Point represents a point (group element) on the secp256k1 elliptic curve.
 type Point struct {
 	inner C.secp256k1_gej
@@ -92,6 +100,48 @@ func NewPointInfinity() Point {
 	return p
 }
 
+// The nothing-up-my-sleeve generator returned by NewPointH.
+var pointH = nothingUpMySleevePoint()
+
+// NewPointH returns a second generator H of the curve group for which no one
+// knows the discrete logarithm with respect to the canonical generator G. Its x
+// coordinate is the smallest number not less than the SHA-256 hash of the 65
+// byte uncompressed encoding of G that is the x coordinate of a curve point,
+// and its y coordinate is even. This is the same point that is used by BIP-341
+// and by confidential transactions, and has x coordinate
+//
+//	0x50929b74c1a04954b78b4b6035e97a5e078a5a0f28ec96d547bfee9ace803ac0
+//
+// which is the hash itself.
+func NewPointH() Point {
+	return pointH
+}
+
+// nothingUpMySleevePoint derives the point returned by NewPointH by hashing the
+// canonical generator and then incrementing the x coordinate until it
+// corresponds to a curve point.
+func nothingUpMySleevePoint() Point {
+	var bs [65]byte
+	var g C.secp256k1_ge
+	C.ge_const_g(&g)
+	bs[0] = 0x04
+	putB32From5x52(bs[1:33], &g.x)
+	putB32From5x52(bs[33:65], &g.y)
+	digest := sha256.Sum256(bs[:])
+
+	var p Point
+	var tmp C.secp256k1_ge
+	var x Fp
+	one := NewFpFromU64(1)
+	x.SetB32(digest[:])
+	for C.secp256k1_ge_set_xo_var(&tmp, &x.inner, 0) == 0 {
+		x.Add(&x, &one)
+	}
+	C.secp256k1_fe_normalize_var(&tmp.y)
+	C.secp256k1_gej_set_ge(&p.inner, &tmp)
+	return p
+}
+
 // RandomPoint generates a random point on the elliptic curve.
 //
 // Panics: This function will panic if there was an error reading bytes from
@@ -106,18 +156,28 @@ func RandomPoint() Point {
 
 // RandomPointNoPanic generates a random point on the elliptic curve.
 func RandomPointNoPanic() (Point, error) {
+	return RandomPointFrom(rand.Reader)
+}
+
+// RandomPointFrom generates a random point on the elliptic curve using the
+// given source of randomness, or returns an error if it could not read from
+// the source. The result is deterministic if the source is.
+func RandomPointFrom(r io.Reader) (Point, error) {
 	var p Point
 	var tmp C.secp256k1_ge
 	var bs [1]byte
 
-	_, err := rand.Read(bs[:])
+	_, err := io.ReadFull(r, bs[:])
 	if err != nil {
 		return Point{}, err
 	}
 
 	b := bs[0] & 1
 	for {
-		x := RandomFp()
+		x, err := RandomFpFrom(r)
+		if err != nil {
+			return Point{}, err
+		}
 		if C.secp256k1_ge_set_xo_var(&tmp, &x.inner, C.int(b)) != 0 {
 			C.secp256k1_fe_normalize_var(&tmp.y)
 			C.secp256k1_gej_set_ge(&p.inner, &tmp)
@@ -163,7 +223,10 @@ func (p *Point) XY() (Fp, Fp, error) {
 	return x, y, nil
 }
 
-// PutBytes stores the bytes of the field element into destination slice.
+// PutBytes stores the 33 byte encoding of the curve point into the destination
+// slice. The first byte is 0x00 if the y coordinate is even and 0x01 if it is
+// odd, and is followed by the 32 byte big endian x coordinate. The point at
+// infinity is encoded as 0xFF followed by 32 zero bytes.
 //
 // Panics: If the byte slice has length less than 33, this function will panic.
 func (p *Point) PutBytes(dst []byte) {
@@ -178,11 +241,16 @@ func (p *Point) PutBytes(dst []byte) {
 	C.secp256k1_fe_normalize_var(&tmp.y)
 
 	if pCopy.IsInfinity() {
+		// The coordinates of the point at infinity are meaningless, so they
+		// are not encoded to make the encoding unique.
 		dst[0] = 0xFF
-	} else {
-		dst[0] = byte(tmp.y.n[0] & 1)
+		for i := 1; i < PointSizeMarshalled; i++ {
+			dst[i] = 0
+		}
+		return
 	}
 
+	dst[0] = byte(tmp.y.n[0] & 1)
 	putB32From5x52(dst[1:PointSizeMarshalled], &tmp.x)
 }
 
@@ -246,6 +314,243 @@ func (p *Point) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
 	return buf[PointSizeMarshalled:], rem - PointSize, nil
 }
 
+// String implements the fmt.Stringer interface. The curve point is printed as
+// its affine coordinates in hex, or as "∞" if it is the point at infinity.
+func (p Point) String() string {
+	return fmt.Sprint(p)
+}
+
+// Format implements the fmt.Formatter interface. The %x and %X verbs print the
+// 33 byte representation of the curve point given by PutBytes in hex, with a
+// 0x prefix if the # flag is given. The %v and %s verbs print the affine
+// coordinates of the curve point in hex, and %d prints them in decimal. The
+// point at infinity is printed as "∞" by all verbs other than %x and %X.
+func (p Point) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'x', 'X':
+		var bs [PointSizeMarshalled]byte
+		p.PutBytes(bs[:])
+		formatField(f, verb, p, bs[:])
+	case 'v', 's', 'd':
+		x, y, err := p.XY()
+		if err != nil {
+			writePadded(f, "∞")
+			return
+		}
+		format := "(%" + string(verb) + ", %" + string(verb) + ")"
+		writePadded(f, fmt.Sprintf(format, x, y))
+	default:
+		fmt.Fprintf(f, "%%!%c(%T=%v)", verb, p, p)
+	}
+}
+
+// UnmarshalStrict is the same as Unmarshal, except that it only accepts the
+// encodings that are accepted by UnmarshalBinary. If an error is returned, the
+// receiver is left unchanged. To select strict decoding for a curve point
+// nested in another type, use StrictPoint.
+func (p *Point) UnmarshalStrict(buf []byte, rem int) ([]byte, int, error) {
+	if len(buf) < PointSizeMarshalled || rem < PointSize {
+		return buf, rem, surge.ErrUnexpectedEndOfBuffer
+	}
+
+	if err := p.UnmarshalBinary(buf[:PointSizeMarshalled]); err != nil {
+		return buf, rem, err
+	}
+
+	return buf[PointSizeMarshalled:], rem - PointSize, nil
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface. The curve
+// point is encoded in the same 33 byte form as PutBytes.
+func (p Point) MarshalBinary() ([]byte, error) {
+	bs := make([]byte, PointSizeMarshalled)
+	p.PutBytes(bs)
+	return bs, nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface. Unlike
+// SetBytes, only the exact encodings produced by PutBytes are accepted: an
+// error is returned if the data does not have length 33, if the first byte is
+// not 0x00, 0x01 or 0xFF, if the x coordinate is not less than P, or if it
+// does not correspond to a curve point. The point at infinity must be encoded
+// as 0xFF followed by zeros. Encodings of the point at infinity with non zero
+// bytes, and x coordinates that are not less than P, give a
+// *NonCanonicalError. If an error is returned, the receiver is left unchanged.
+func (p *Point) UnmarshalBinary(data []byte) error {
+	if len(data) != PointSizeMarshalled {
+		return fmt.Errorf("invalid length: expected %v bytes, got %v", PointSizeMarshalled, len(data))
+	}
+
+	switch data[0] {
+	case 0xFF:
+		for _, b := range data[1:] {
+			if b != 0 {
+				return &NonCanonicalError{Type: "Point"}
+			}
+		}
+		p.inner.infinity = 1
+		return nil
+
+	case 0x00, 0x01:
+		var tmp C.secp256k1_ge
+		if !set5x52FromB32(data[1:], &tmp.x) {
+			return &NonCanonicalError{Type: "Point"}
+		}
+		if C.secp256k1_ge_set_xo_var(&tmp, &tmp.x, C.int(data[0])) == 0 {
+			return errors.New("invalid curve point data: x coordinate is not on the curve")
+		}
+		C.secp256k1_fe_normalize_var(&tmp.y)
+		C.secp256k1_gej_set_ge(&p.inner, &tmp)
+		return nil
+
+	default:
+		return fmt.Errorf("invalid curve point data: unexpected prefix 0x%02x", data[0])
+	}
+}
+
+// MarshalText implements the encoding.TextMarshaler interface. The curve point
+// is encoded as the hex string of its binary representation.
+func (p Point) MarshalText() ([]byte, error) {
+	return marshalHexText(p)
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface, with the
+// same checks as UnmarshalBinary.
+func (p *Point) UnmarshalText(text []byte) error {
+	return unmarshalHexText(p, text)
+}
+
+// MarshalJSON implements the json.Marshaler interface. The curve point is
+// encoded as a JSON string containing its text representation.
+func (p Point) MarshalJSON() ([]byte, error) {
+	return marshalJSONText(p)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, with the same
+// checks as UnmarshalBinary.
+func (p *Point) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(p, data)
+}
+
+// PutSEC1Compressed stores the SEC1 compressed encoding of the curve point
+// into the destination slice and returns the number of bytes written. This is
+// a 0x02 or 0x03 byte, depending on the parity of the y coordinate, followed
+// by the 32 byte big endian x coordinate. The point at infinity is encoded as
+// the single byte 0x00.
+//
+// Panics: If the byte slice has length less than 33, this function will panic.
+func (p *Point) PutSEC1Compressed(dst []byte) int {
+	if len(dst) < PointSizeSEC1Compressed {
+		panic(fmt.Sprintf("invalid slice length: length needs to be at least 33, got %v", len(dst)))
+	}
+
+	if p.IsInfinity() {
+		dst[0] = 0x00
+		return 1
+	}
+
+	var tmp C.secp256k1_ge
+	p.putAffine(&tmp)
+
+	dst[0] = 0x02 | byte(tmp.y.n[0]&1)
+	putB32From5x52(dst[1:33], &tmp.x)
+
+	return PointSizeSEC1Compressed
+}
+
+// PutSEC1Uncompressed stores the SEC1 uncompressed encoding of the curve point
+// into the destination slice and returns the number of bytes written. This is
+// a 0x04 byte followed by the 32 byte big endian x and y coordinates. The
+// point at infinity is encoded as the single byte 0x00.
+//
+// Panics: If the byte slice has length less than 65, this function will panic.
+func (p *Point) PutSEC1Uncompressed(dst []byte) int {
+	if len(dst) < PointSizeSEC1Uncompressed {
+		panic(fmt.Sprintf("invalid slice length: length needs to be at least 65, got %v", len(dst)))
+	}
+
+	if p.IsInfinity() {
+		dst[0] = 0x00
+		return 1
+	}
+
+	var tmp C.secp256k1_ge
+	p.putAffine(&tmp)
+
+	dst[0] = 0x04
+	putB32From5x52(dst[1:33], &tmp.x)
+	putB32From5x52(dst[33:65], &tmp.y)
+
+	return PointSizeSEC1Uncompressed
+}
+
+// SetSEC1 sets the curve point to be equal to the point represented by the
+// given SEC1 encoding. The compressed (0x02 and 0x03), uncompressed (0x04)
+// and hybrid (0x06 and 0x07) forms are accepted, as is the single byte 0x00
+// for the point at infinity. The slice must contain exactly one encoding. An
+// error is returned, and the receiver is left unchanged, if the prefix or
+// length is invalid, if a coordinate is not less than P, if the parity in a
+// hybrid prefix does not match the y coordinate, or if the point is not on the
+// curve.
+func (p *Point) SetSEC1(bs []byte) error {
+	var tmp C.secp256k1_ge
+
+	switch {
+	case len(bs) == 1 && bs[0] == 0x00:
+		p.inner.infinity = 1
+		return nil
+
+	case len(bs) == PointSizeSEC1Compressed && (bs[0] == 0x02 || bs[0] == 0x03):
+		if !set5x52FromB32(bs[1:33], &tmp.x) {
+			return &NonCanonicalError{Type: "Point"}
+		}
+		if C.secp256k1_ge_set_xo_var(&tmp, &tmp.x, C.int(bs[0])&1) == 0 {
+			return errors.New("invalid curve point data: x coordinate is not on the curve")
+		}
+
+		// After reconstructing the y coordinate, it is not guaranteed to be
+		// normalized, so we do that manually.
+		C.secp256k1_fe_normalize_var(&tmp.y)
+
+	case len(bs) == PointSizeSEC1Uncompressed && (bs[0] == 0x04 || bs[0] == 0x06 || bs[0] == 0x07):
+		var x, y C.secp256k1_fe
+		if !set5x52FromB32(bs[1:33], &x) || !set5x52FromB32(bs[33:65], &y) {
+			return errors.New("invalid curve point data: coordinate is not less than P")
+		}
+		if bs[0] != 0x04 && C.uint64_t(bs[0]&1) != y.n[0]&1 {
+			return errors.New("invalid curve point data: hybrid prefix does not match the parity of the y coordinate")
+		}
+		C.secp256k1_ge_set_xy(&tmp, &x, &y)
+		if C.secp256k1_ge_is_valid_var(&tmp) == 0 {
+			return errors.New("invalid curve point data: point is not on the curve")
+		}
+
+	default:
+		return fmt.Errorf("invalid curve point data: unexpected prefix 0x%02x for length %v", firstByte(bs), len(bs))
+	}
+
+	C.secp256k1_gej_set_ge(&p.inner, &tmp)
+
+	return nil
+}
+
+// putAffine stores the normalized affine coordinates of the curve point, which
+// must not be the point at infinity, in the given group element.
+func (p *Point) putAffine(dst *C.secp256k1_ge) {
+	pCopy := *p
+	C.secp256k1_ge_set_gej(dst, &pCopy.inner)
+	C.secp256k1_fe_normalize_var(&dst.x)
+	C.secp256k1_fe_normalize_var(&dst.y)
+}
+
+// firstByte returns the first byte of the given slice, or zero if it is empty.
+func firstByte(bs []byte) byte {
+	if len(bs) == 0 {
+		return 0
+	}
+	return bs[0]
+}
+
 // IsInfinity returns true if the point represents the point at infinity, and
 // false otherwise.
 func (p *Point) IsInfinity() bool {
@@ -296,7 +601,7 @@ func (p *Point) Eq(other *Point) bool {
 }
 
 // BaseExp computes the scalar multiplication of the canonical generator of the
-// curve by the given scalar.
+// curve by the given scalar. See BaseExpUnsafe for details.
 func (p *Point) BaseExp(scalar *Fn) {
 	if scalar == nil {
 		panic("expected first argument to not be nil")
@@ -306,13 +611,16 @@ func (p *Point) BaseExp(scalar *Fn) {
 }
 
 // BaseExpUnsafe computes the scalar multiplication of the canonical generator
-// of the curve by the given scalar.
+// of the curve by the given scalar. The computation is constant time and uses
+// precomputed tables of multiples of the generator, which are built the first
+// time this function is called, and blinding that can be reseeded using
+// ReseedBaseExpBlinding.
 //
 // Unsafe: If this function receives nil arguments, the behaviour is
 // implementation dependent, because the definition of the NULL pointer in c is
 // implementation dependent.
 func (p *Point) BaseExpUnsafe(scalar *Fn) {
-	scalarMul(&p.inner, &C.secp256k1_generator, &scalar.inner)
+	ecmultGen(&p.inner, &scalar.inner)
 }
 
 // Scale computes the scalar multiplication of the given curve point by the
@@ -1,8 +1,14 @@
 package secp256k1_test
 
 import (
+	"bytes"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/dustinxie/ecc"
@@ -11,6 +17,7 @@ import (
 	. "github.com/onsi/gomega"
 	. "github.com/renproject/secp256k1"
 	"github.com/renproject/secp256k1/secp256k1tutil"
+	"github.com/renproject/surge"
 )
 
 var _ = Describe("Point", func() {
@@ -274,6 +281,35 @@ var _ = Describe("Point", func() {
 		}
 	})
 
+	It("should encode the point at infinity as 0xFF followed by zeros", func() {
+		expected := make([]byte, PointSizeMarshalled)
+		expected[0] = 0xFF
+
+		for i := 0; i < trials; i++ {
+			// The internal coordinates of a point at infinity that is the
+			// result of a computation are not zero.
+			var p, neg Point
+			p = RandomPoint()
+			neg.Negate(&p)
+			p.Add(&p, &neg)
+			Expect(p.IsInfinity()).To(BeTrue())
+
+			bs := make([]byte, PointSizeMarshalled)
+			for j := range bs {
+				bs[j] = 0xAA
+			}
+			p.PutBytes(bs)
+			Expect(bs).To(Equal(expected))
+
+			for j := range bs {
+				bs[j] = 0xAA
+			}
+			_, _, err := p.Marshal(bs, p.SizeHint())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bs).To(Equal(expected))
+		}
+	})
+
 	It("should be equal after marshaling and unmarshaling with surge", func() {
 		var bs [PointSizeMarshalled]byte
 		var before, after Point
@@ -362,6 +398,318 @@ var _ = Describe("Point", func() {
 		}
 	})
 
+	It("should encode points in the SEC1 forms correctly", func() {
+		var p Point
+		var compressed [PointSizeSEC1Compressed]byte
+		var uncompressed [PointSizeSEC1Uncompressed]byte
+		expected := make([]byte, PointSizeSEC1Uncompressed)
+
+		for i := 0; i < trials; i++ {
+			// Compute the point independently using the ecc library.
+			k := RandomFn()
+			var kBytes [32]byte
+			k.PutB32(kBytes[:])
+			x, y := params.ScalarBaseMult(kBytes[:])
+			p.BaseExp(&k)
+
+			expected[0] = 0x04
+			copyLeftPadZero(expected[1:33], x.Bytes())
+			copyLeftPadZero(expected[33:65], y.Bytes())
+			Expect(p.PutSEC1Uncompressed(uncompressed[:])).To(Equal(PointSizeSEC1Uncompressed))
+			Expect(uncompressed[:]).To(Equal(expected))
+
+			expected[0] = 0x02 | byte(y.Bit(0))
+			Expect(p.PutSEC1Compressed(compressed[:])).To(Equal(PointSizeSEC1Compressed))
+			Expect(compressed[:]).To(Equal(expected[:33]))
+		}
+	})
+
+	It("should be equal after converting to and from the SEC1 forms", func() {
+		var p, q Point
+		var compressed [PointSizeSEC1Compressed]byte
+		var uncompressed [PointSizeSEC1Uncompressed]byte
+
+		for i := 0; i < trials; i++ {
+			p = RandomPoint()
+
+			n := p.PutSEC1Compressed(compressed[:])
+			Expect(q.SetSEC1(compressed[:n])).To(Succeed())
+			Expect(q.Eq(&p)).To(BeTrue())
+
+			n = p.PutSEC1Uncompressed(uncompressed[:])
+			Expect(q.SetSEC1(uncompressed[:n])).To(Succeed())
+			Expect(q.Eq(&p)).To(BeTrue())
+
+			// Hybrid encodings have the parity of y in the prefix.
+			uncompressed[0] = 0x06 | (uncompressed[64] & 1)
+			Expect(q.SetSEC1(uncompressed[:])).To(Succeed())
+			Expect(q.Eq(&p)).To(BeTrue())
+		}
+	})
+
+	It("should encode the point at infinity as a single zero byte in the SEC1 forms", func() {
+		var p Point
+		var bs [PointSizeSEC1Uncompressed]byte
+
+		for i := range bs {
+			bs[i] = 0xAA
+		}
+		Expect(inf.PutSEC1Compressed(bs[:])).To(Equal(1))
+		Expect(bs[0]).To(Equal(byte(0x00)))
+
+		bs[0] = 0xAA
+		Expect(inf.PutSEC1Uncompressed(bs[:])).To(Equal(1))
+		Expect(bs[0]).To(Equal(byte(0x00)))
+
+		p = RandomPoint()
+		Expect(p.SetSEC1(bs[:1])).To(Succeed())
+		Expect(p.IsInfinity()).To(BeTrue())
+	})
+
+	It("should return an error when setting invalid SEC1 data", func() {
+		var p, before Point
+		var compressed [PointSizeSEC1Compressed]byte
+		var uncompressed [PointSizeSEC1Uncompressed]byte
+
+		for i := 0; i < trials/10; i++ {
+			p = RandomPoint()
+			before = p
+			p.PutSEC1Compressed(compressed[:])
+			p.PutSEC1Uncompressed(uncompressed[:])
+
+			invalid := [][]byte{
+				// Invalid lengths and prefixes.
+				{},
+				{0x01},
+				{0x00, 0x00},
+				compressed[:32],
+				append(compressed[:], 0x00),
+				uncompressed[:64],
+				append(uncompressed[:], 0x00),
+				append([]byte{0x04}, compressed[1:]...),
+				append([]byte{0x05}, uncompressed[1:]...),
+				append([]byte{0x02}, uncompressed[1:]...),
+			}
+
+			// A hybrid prefix with the wrong parity.
+			hybrid := append([]byte{}, uncompressed[:]...)
+			hybrid[0] = 0x07 - (hybrid[64] & 1)
+			invalid = append(invalid, hybrid)
+
+			// A point that is not on the curve.
+			offCurve := append([]byte{}, uncompressed[:]...)
+			offCurve[64] ^= 0x02
+			invalid = append(invalid, offCurve)
+
+			for _, bs := range invalid {
+				Expect(p.SetSEC1(bs)).ToNot(Succeed())
+				Expect(p.Eq(&before)).To(BeTrue())
+			}
+		}
+
+		// There is no curve point with an x coordinate of 5.
+		bs := make([]byte, PointSizeSEC1Compressed)
+		bs[0], bs[32] = 0x02, 5
+		Expect(p.SetSEC1(bs)).ToNot(Succeed())
+
+		// Coordinates that are not less than P are rejected, even though the
+		// reduced coordinates represent a valid curve point. There is a curve
+		// point with an x coordinate of 1.
+		bs[32] = 1
+		Expect(p.SetSEC1(bs)).To(Succeed())
+		x, y, _ := p.XY()
+
+		long := make([]byte, PointSizeSEC1Uncompressed)
+		long[0] = 0x04
+		x.PutB32(long[1:33])
+		y.PutB32(long[33:65])
+		Expect(p.SetSEC1(long)).To(Succeed())
+
+		copyLeftPadZero(bs[1:], new(big.Int).Add(x.Int(), params.Params().P).Bytes())
+		copyLeftPadZero(long[1:33], bs[1:])
+		Expect(p.SetSEC1(bs)).ToNot(Succeed())
+		Expect(p.SetSEC1(long)).ToNot(Succeed())
+	})
+
+	It("should be equal after marshaling and unmarshaling in binary", func() {
+		var before, after Point
+		var bs [PointSizeMarshalled]byte
+		for i := 0; i < trials; i++ {
+			before = RandomPoint()
+			if i == 0 {
+				before = inf
+			}
+
+			data, err := before.MarshalBinary()
+			Expect(err).ToNot(HaveOccurred())
+			before.PutBytes(bs[:])
+			Expect(data).To(Equal(bs[:]))
+
+			Expect(after.UnmarshalBinary(data)).To(Succeed())
+			Expect(after.Eq(&before)).To(BeTrue())
+		}
+	})
+
+	It("should encode the point at infinity uniquely", func() {
+		var p Point
+		p.Add(&inf, &inf)
+		data, err := p.MarshalBinary()
+		Expect(err).ToNot(HaveOccurred())
+
+		expected := make([]byte, PointSizeMarshalled)
+		expected[0] = 0xFF
+		Expect(data).To(Equal(expected))
+	})
+
+	It("should be equal after marshaling and unmarshaling as text and JSON", func() {
+		type wrapper struct {
+			Value Point  `json:"value"`
+			Ptr   *Point `json:"ptr"`
+		}
+
+		var bs [PointSizeMarshalled]byte
+		var after Point
+		for i := 0; i < trials; i++ {
+			x, y := RandomPoint(), RandomPoint()
+
+			text, err := x.MarshalText()
+			Expect(err).ToNot(HaveOccurred())
+			x.PutBytes(bs[:])
+			Expect(string(text)).To(Equal(hex.EncodeToString(bs[:])))
+			Expect(after.UnmarshalText(text)).To(Succeed())
+			Expect(after.Eq(&x)).To(BeTrue())
+
+			before := wrapper{Value: x, Ptr: &y}
+			data, err := json.Marshal(before)
+			Expect(err).ToNot(HaveOccurred())
+
+			var decoded wrapper
+			Expect(json.Unmarshal(data, &decoded)).To(Succeed())
+			Expect(decoded.Value.Eq(&before.Value)).To(BeTrue())
+			Expect(decoded.Ptr.Eq(before.Ptr)).To(BeTrue())
+		}
+	})
+
+	It("should return an error when unmarshaling non canonical or invalid data", func() {
+		var p, before Point
+		var bs [PointSizeMarshalled]byte
+		for i := 0; i < trials/10; i++ {
+			p = RandomPoint()
+			before = p
+			p.PutBytes(bs[:])
+
+			invalid := [][]byte{
+				{},
+				bs[:32],
+				append(bs[:], 0x00),
+			}
+
+			// Prefixes other than 0x00, 0x01 and 0xFF.
+			for _, prefix := range []byte{0x02, 0x03, 0x04, 0xFE} {
+				data := append([]byte{}, bs[:]...)
+				data[0] = prefix
+				invalid = append(invalid, data)
+			}
+
+			// The point at infinity with a non zero coordinate.
+			data := append([]byte{}, bs[:]...)
+			data[0] = 0xFF
+			invalid = append(invalid, data)
+
+			for _, data := range invalid {
+				Expect(p.UnmarshalBinary(data)).ToNot(Succeed())
+				Expect(p.UnmarshalText([]byte(hex.EncodeToString(data)))).ToNot(Succeed())
+				Expect(p.Eq(&before)).To(BeTrue())
+			}
+		}
+
+		// There is no curve point with an x coordinate of 5.
+		data := make([]byte, PointSizeMarshalled)
+		data[32] = 5
+		Expect(p.UnmarshalBinary(data)).ToNot(Succeed())
+
+		// There is a curve point with an x coordinate of 1, but the non
+		// canonical encoding of x + P should be rejected.
+		data[32] = 1
+		Expect(p.UnmarshalBinary(data)).To(Succeed())
+		copyLeftPadZero(data[1:], new(big.Int).Add(big.NewInt(1), params.Params().P).Bytes())
+		Expect(p.UnmarshalBinary(data)).ToNot(Succeed())
+		Expect(p.SetBytes(data)).To(Succeed())
+
+		Expect(p.UnmarshalJSON([]byte(`"zz"`))).ToNot(Succeed())
+	})
+
+	It("should treat JSON null as a no-op when unmarshaling", func() {
+		type wrapper struct {
+			Value Point  `json:"value"`
+			Ptr   *Point `json:"ptr"`
+		}
+
+		for i := 0; i < trials/10; i++ {
+			before := RandomPoint()
+			p := before
+			Expect(p.UnmarshalJSON([]byte("null"))).To(Succeed())
+			Expect(p.Eq(&before)).To(BeTrue())
+
+			decoded := wrapper{Value: before}
+			Expect(json.Unmarshal([]byte(`{"value": null, "ptr": null}`), &decoded)).To(Succeed())
+			Expect(decoded.Value.Eq(&before)).To(BeTrue())
+			Expect(decoded.Ptr).To(BeNil())
+		}
+	})
+
+	It("should decode strictly with surge when converted to StrictPoint", func() {
+		var p Point
+
+		// The non canonical encodings of the point with x coordinate 1 and
+		// of the point at infinity.
+		nonCanonicalX := make([]byte, PointSizeMarshalled)
+		copyLeftPadZero(nonCanonicalX[1:], new(big.Int).Add(big.NewInt(1), params.Params().P).Bytes())
+		nonZeroInf := make([]byte, PointSizeMarshalled)
+		nonZeroInf[0], nonZeroInf[32] = 0xFF, 1
+
+		for _, data := range [][]byte{nonCanonicalX, nonZeroInf} {
+			err := surge.FromBinary((*StrictPoint)(&p), data)
+			Expect(err).To(BeAssignableToTypeOf(&NonCanonicalError{}))
+			_, _, err = p.UnmarshalStrict(data, PointSize)
+			Expect(err).To(BeAssignableToTypeOf(&NonCanonicalError{}))
+
+			// Decoding without the conversion accepts the data.
+			Expect(surge.FromBinary(&p, data)).To(Succeed())
+		}
+
+		for i := 0; i < trials; i++ {
+			before := RandomPoint()
+			bs, err := surge.ToBinary(StrictPoint(before))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(surge.FromBinary((*StrictPoint)(&p), bs)).To(Succeed())
+			Expect(p.Eq(&before)).To(BeTrue())
+		}
+	})
+
+	It("should format curve points correctly", func() {
+		var bs [PointSizeMarshalled]byte
+		for i := 0; i < trials; i++ {
+			p := RandomPoint()
+			x, y, _ := p.XY()
+			p.PutBytes(bs[:])
+			h := hex.EncodeToString(bs[:])
+
+			affine := "(" + x.String() + ", " + y.String() + ")"
+			Expect(p.String()).To(Equal(affine))
+			Expect(fmt.Sprintf("%v", p)).To(Equal(affine))
+			Expect(fmt.Sprintf("%s", &p)).To(Equal(affine))
+			Expect(fmt.Sprintf("%d", p)).To(Equal("(" + x.Int().String() + ", " + y.Int().String() + ")"))
+			Expect(fmt.Sprintf("%x", p)).To(Equal(h))
+			Expect(fmt.Sprintf("%#X", p)).To(Equal("0X" + strings.ToUpper(h)))
+		}
+
+		Expect(inf.String()).To(Equal("∞"))
+		Expect(fmt.Sprintf("%d", inf)).To(Equal("∞"))
+		Expect(fmt.Sprintf("%3v|", inf)).To(Equal("  ∞|"))
+		Expect(fmt.Sprintf("%x", inf)).To(Equal("ff" + strings.Repeat("0", 64)))
+	})
+
 	//
 	// Panics
 	//
@@ -382,6 +730,17 @@ var _ = Describe("Point", func() {
 		}
 	})
 
+	It("should panic when putting SEC1 bytes when the slice length is too small", func() {
+		p := RandomPoint()
+		var bs [PointSizeSEC1Uncompressed]byte
+		for i := 0; i < PointSizeSEC1Compressed; i++ {
+			Expect(func() { p.PutSEC1Compressed(bs[:i]) }).To(Panic())
+		}
+		for i := 0; i < PointSizeSEC1Uncompressed; i++ {
+			Expect(func() { p.PutSEC1Uncompressed(bs[:i]) }).To(Panic())
+		}
+	})
+
 	It("should panic when base exponentiating when the argument is nil", func() {
 		var p Point
 		Expect(func() { p.BaseExp(nil) }).To(Panic())
@@ -562,6 +921,66 @@ var _ = Describe("Point", func() {
 		})
 	})
 
+	It("should generate the same random curve points from readers with the same seed", func() {
+		r1, r2 := secp256k1tutil.NewSeededReader(42), secp256k1tutil.NewSeededReader(42)
+		for i := 0; i < trials; i++ {
+			x, err := RandomPointFrom(r1)
+			Expect(err).ToNot(HaveOccurred())
+			y, err := RandomPointFrom(r2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(x.Eq(&y)).To(BeTrue())
+			Expect(x.IsOnCurve()).To(BeTrue())
+		}
+	})
+
+	It("should generate different random curve points from readers with different seeds", func() {
+		r1, r2 := secp256k1tutil.NewSeededReader(1), secp256k1tutil.NewSeededReader(2)
+		for i := 0; i < trials; i++ {
+			x, err := RandomPointFrom(r1)
+			Expect(err).ToNot(HaveOccurred())
+			y, err := RandomPointFrom(r2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(x.Eq(&y)).To(BeFalse())
+		}
+	})
+
+	It("should return an error when the given reader runs out of bytes", func() {
+		_, err := RandomPointFrom(bytes.NewReader(make([]byte, 16)))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return the nothing-up-my-sleeve generator", func() {
+		var G Point
+		one := NewFnFromU16(1)
+		G.BaseExp(&one)
+
+		H := NewPointH()
+		Expect(H.IsOnCurve()).To(BeTrue())
+		Expect(H.HasEvenY()).To(BeTrue())
+		Expect(H.Eq(&G)).To(BeFalse())
+
+		gx, gy, err := G.XY()
+		Expect(err).ToNot(HaveOccurred())
+		var gBytes [65]byte
+		gBytes[0] = 0x04
+		gx.PutB32(gBytes[1:33])
+		gy.PutB32(gBytes[33:65])
+		digest := sha256.Sum256(gBytes[:])
+
+		// The hash of G is already the x coordinate of a curve point.
+		hx, _, err := H.XY()
+		Expect(err).ToNot(HaveOccurred())
+		var hxBytes [32]byte
+		hx.PutB32(hxBytes[:])
+		Expect(hxBytes).To(Equal(digest))
+		Expect(hex.EncodeToString(hxBytes[:])).To(Equal("50929b74c1a04954b78b4b6035e97a5e078a5a0f28ec96d547bfee9ace803ac0"))
+
+		// Modifying the returned point does not modify the generator.
+		H.Negate(&H)
+		H2 := NewPointH()
+		Expect(H2.HasEvenY()).To(BeTrue())
+	})
+
 	Context("regression tests", func() {
 		bad := "104594261325654521456437189213270314662855164308234370710339505268369968110053"
 		bady := "63841638568451640125764236139710619670518082485545205673739600116664829291880"
@@ -0,0 +1,109 @@
+package schnorr
+
+import (
+	"fmt"
+
+	"github.com/renproject/secp256k1"
+)
+
+// VerifyBatch verifies the given signatures of the given messages for the
+// given public keys, where the ith signature is checked against the ith message
+// and public key. It returns true if all of the signatures are valid. Otherwise
+// it returns false along with the indices of the invalid signatures in
+// increasing order.
+//
+// The signatures are first checked together by combining them with random
+// weights into a single multi scalar multiplication, which is significantly
+// faster than verifying them individually. Only if this check fails are the
+// signatures verified individually to identify the invalid ones. An empty
+// batch is valid.
+//
+// Panics: This function will panic if the slices do not all have the same
+// length.
+func VerifyBatch(pubs []PubKey, msgs [][32]byte, sigs []Signature) (bool, []int) {
+	if len(pubs) != len(sigs) || len(msgs) != len(sigs) {
+		panic(fmt.Sprintf("different length slices: %v public keys, %v messages and %v signatures", len(pubs), len(msgs), len(sigs)))
+	}
+	if len(sigs) == 0 {
+		return true, nil
+	}
+
+	if verifyBatch(pubs, msgs, sigs) {
+		return true, nil
+	}
+
+	var invalid []int
+	for i := range sigs {
+		if !Verify(&pubs[i], msgs[i], &sigs[i]) {
+			invalid = append(invalid, i)
+		}
+	}
+
+	// The combined check can only fail when at least one signature is invalid,
+	// except with negligible probability or when the random weights could not
+	// be generated; in either case the individual checks are authoritative.
+	return len(invalid) == 0, invalid
+}
+
+// verifyBatch checks that
+//
+//	(a_1*s_1 + ... + a_n*s_n)*G = a_1*R_1 + ... + a_n*R_n + a_1*e_1*P_1 + ... + a_n*e_n*P_n
+//
+// where a_1 = 1 and the remaining weights a_i are uniformly random. It
+// returns false if any of the signatures or public keys are malformed, or if
+// the random weights could not be generated.
+func verifyBatch(pubs []PubKey, msgs [][32]byte, sigs []Signature) bool {
+	n := len(sigs)
+
+	// The terms are laid out as [G, R_1, ..., R_n, P_1, ..., P_n], and the
+	// check is that their weighted sum is the point at infinity.
+	points := make([]secp256k1.Point, 2*n+1)
+	scalars := make([]secp256k1.Fn, 2*n+1)
+
+	one := secp256k1.NewFnFromU16(1)
+	points[0].BaseExp(&one)
+
+	var a, e, term secp256k1.Fn
+	var px, rx [32]byte
+	var compressed [secp256k1.PointSizeMarshalled]byte
+	compressed[0] = 0x02
+	for i := range sigs {
+		pub := &pubs[i].point
+		if !putValidX(px[:], pub) {
+			return false
+		}
+
+		// Lift the x coordinate of the nonce point to the point with an even
+		// y coordinate.
+		sigs[i].R.PutB32(rx[:])
+		copy(compressed[1:], rx[:])
+		if err := points[i+1].SetBytes(compressed[:]); err != nil {
+			return false
+		}
+		points[n+i+1] = *pub
+
+		if i == 0 {
+			a = one
+		} else {
+			var err error
+			if a, err = secp256k1.RandomFnNoPanic(); err != nil {
+				return false
+			}
+		}
+
+		challenge := taggedHash(&tagChallenge, rx[:], px[:], msgs[i][:])
+		e.SetB32(challenge[:])
+
+		// Accumulate -a_i*s_i as the weight of G.
+		term.Mul(&a, &sigs[i].S)
+		term.Negate(&term)
+		scalars[0].Add(&scalars[0], &term)
+
+		scalars[i+1] = a
+		scalars[n+i+1].Mul(&a, &e)
+	}
+
+	var sum secp256k1.Point
+	sum.MultiScaleVar(points, scalars)
+	return sum.IsInfinity()
+}
@@ -0,0 +1,150 @@
+package schnorr_test
+
+import (
+	"crypto/rand"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/renproject/secp256k1"
+	. "github.com/renproject/secp256k1/schnorr"
+)
+
+func randomBatch(n int) ([]PubKey, [][32]byte, []Signature) {
+	pubs := make([]PubKey, n)
+	msgs := make([][32]byte, n)
+	sigs := make([]Signature, n)
+	for i := 0; i < n; i++ {
+		var aux [32]byte
+		if _, err := rand.Read(msgs[i][:]); err != nil {
+			panic(err)
+		}
+		if _, err := rand.Read(aux[:]); err != nil {
+			panic(err)
+		}
+
+		priv := secp256k1.RandomFn()
+		pubs[i] = NewPubKey(&priv)
+		sig, err := Sign(&priv, msgs[i], aux)
+		if err != nil {
+			panic(err)
+		}
+		sigs[i] = sig
+	}
+	return pubs, msgs, sigs
+}
+
+var _ = Describe("Batch verification", func() {
+	trials := 20
+
+	sizes := []int{1, 2, 3, 10, 50}
+
+	It("should accept batches of valid signatures", func() {
+		for _, n := range sizes {
+			pubs, msgs, sigs := randomBatch(n)
+			valid, invalid := VerifyBatch(pubs, msgs, sigs)
+			Expect(valid).To(BeTrue())
+			Expect(invalid).To(BeEmpty())
+		}
+	})
+
+	It("should accept the empty batch", func() {
+		valid, invalid := VerifyBatch(nil, nil, nil)
+		Expect(valid).To(BeTrue())
+		Expect(invalid).To(BeEmpty())
+	})
+
+	It("should identify the invalid signatures", func() {
+		one := secp256k1.NewFnFromU16(1)
+		for i := 0; i < trials; i++ {
+			pubs, msgs, sigs := randomBatch(10)
+
+			// Invalidate the signatures in different ways.
+			sigs[2].S.Add(&sigs[2].S, &one)
+			msgs[5][0] ^= 1
+			pubs[9] = pubs[0]
+
+			valid, invalid := VerifyBatch(pubs, msgs, sigs)
+			Expect(valid).To(BeFalse())
+			Expect(invalid).To(Equal([]int{2, 5, 9}))
+		}
+	})
+
+	It("should reject a batch when a nonce point is not on the curve", func() {
+		pubs, msgs, sigs := randomBatch(5)
+
+		// There is no curve point with an x coordinate of 5.
+		sigs[3].R = secp256k1.NewFpFromU64(5)
+
+		valid, invalid := VerifyBatch(pubs, msgs, sigs)
+		Expect(valid).To(BeFalse())
+		Expect(invalid).To(Equal([]int{3}))
+	})
+
+	It("should reject a batch containing an invalid public key", func() {
+		pubs, msgs, sigs := randomBatch(5)
+		pubs[1] = PubKey{}
+
+		valid, invalid := VerifyBatch(pubs, msgs, sigs)
+		Expect(valid).To(BeFalse())
+		Expect(invalid).To(Equal([]int{1}))
+	})
+
+	It("should reject invalid signatures that cancel when summed", func() {
+		for i := 0; i < trials; i++ {
+			pubs, msgs, sigs := randomBatch(2)
+
+			// Shifting the S values by opposite amounts leaves the unweighted
+			// sum unchanged, which the random weights must detect.
+			delta := secp256k1.RandomFn()
+			sigs[0].S.Add(&sigs[0].S, &delta)
+			delta.Negate(&delta)
+			sigs[1].S.Add(&sigs[1].S, &delta)
+
+			valid, invalid := VerifyBatch(pubs, msgs, sigs)
+			Expect(valid).To(BeFalse())
+			Expect(invalid).To(Equal([]int{0, 1}))
+		}
+	})
+
+	It("should agree with individual verification", func() {
+		one := secp256k1.NewFnFromU16(1)
+		for i := 0; i < trials; i++ {
+			pubs, msgs, sigs := randomBatch(8)
+			if i%2 == 0 {
+				sigs[i%8].S.Add(&sigs[i%8].S, &one)
+			}
+
+			expected := true
+			for j := range sigs {
+				expected = expected && Verify(&pubs[j], msgs[j], &sigs[j])
+			}
+			valid, _ := VerifyBatch(pubs, msgs, sigs)
+			Expect(valid).To(Equal(expected))
+		}
+	})
+
+	It("should panic when the slices have different lengths", func() {
+		pubs, msgs, sigs := randomBatch(3)
+		Expect(func() { VerifyBatch(pubs[:2], msgs, sigs) }).To(Panic())
+		Expect(func() { VerifyBatch(pubs, msgs[:2], sigs) }).To(Panic())
+		Expect(func() { VerifyBatch(pubs, msgs, sigs[:2]) }).To(Panic())
+	})
+})
+
+func benchmarkVerifyBatch(b *testing.B, n int) {
+	pubs, msgs, sigs := randomBatch(n)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = VerifyBatch(pubs, msgs, sigs)
+	}
+}
+
+func BenchmarkVerifyBatch100(b *testing.B) {
+	benchmarkVerifyBatch(b, 100)
+}
+
+func BenchmarkVerifyBatch1000(b *testing.B) {
+	benchmarkVerifyBatch(b, 1000)
+}
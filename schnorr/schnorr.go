@@ -0,0 +1,334 @@
+// Package schnorr implements Schnorr signatures over the secp256k1 curve as
+// defined in BIP-340, using 32 byte x-only public keys.
+package schnorr
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/surge"
+)
+
+// PubKeySizeMarshalled is the number of bytes needed to represent a marshalled
+// x-only public key.
+const PubKeySizeMarshalled int = secp256k1.FpSizeMarshalled
+
+// SignatureSizeMarshalled is the number of bytes needed to represent a
+// marshalled signature.
+const SignatureSizeMarshalled int = secp256k1.FpSizeMarshalled + secp256k1.FnSizeMarshalled
+
+// The SHA-256 hashes of the tags used for the BIP-340 tagged hashes.
+var (
+	tagAux       = sha256.Sum256([]byte("BIP0340/aux"))
+	tagNonce     = sha256.Sum256([]byte("BIP0340/nonce"))
+	tagChallenge = sha256.Sum256([]byte("BIP0340/challenge"))
+)
+
+// taggedHash computes the BIP-340 tagged hash SHA256(tag || tag || data...),
+// where tag is the SHA-256 hash of the tag name.
+func taggedHash(tag *[32]byte, data ...[]byte) [32]byte {
+	var digest [32]byte
+
+	h := sha256.New()
+	h.Write(tag[:])
+	h.Write(tag[:])
+	for _, d := range data {
+		h.Write(d)
+	}
+	h.Sum(digest[:0])
+	return digest
+}
+
+// PubKey represents an x-only public key. Only the x coordinate of the public
+// key is stored; the public key is always taken to be the curve point with
+// that x coordinate and an even y coordinate.
+type PubKey struct {
+	point secp256k1.Point
+}
+
+// NewPubKey returns the x-only public key for the given private key.
+func NewPubKey(priv *secp256k1.Fn) PubKey {
+	var p secp256k1.Point
+	p.BaseExp(priv)
+	return NewPubKeyFromPoint(&p)
+}
+
+// NewPubKeyFromPoint returns the x-only public key with the same x coordinate
+// as the given curve point. If the point has an odd y coordinate, the public
+// key corresponds to its negation.
+//
+// NOTE: It is assumed that the point is not the point at infinity.
+func NewPubKeyFromPoint(p *secp256k1.Point) PubKey {
+	pub := PubKey{point: *p}
+	if !pub.point.HasEvenY() {
+		pub.point.Negate(&pub.point)
+	}
+	return pub
+}
+
+// Point returns the curve point of the public key, which always has an even y
+// coordinate.
+func (pub *PubKey) Point() secp256k1.Point {
+	return pub.point
+}
+
+// Eq returns true if the two public keys are equal, and false otherwise.
+func (pub *PubKey) Eq(other *PubKey) bool {
+	return pub.point.Eq(&other.point)
+}
+
+// PutBytes stores the public key in the given byte slice as the 32 byte big
+// endian encoding of its x coordinate.
+//
+// Panics: If the byte slice has length less than 32, this function will panic.
+func (pub *PubKey) PutBytes(dst []byte) {
+	if len(dst) < PubKeySizeMarshalled {
+		panic(fmt.Sprintf("invalid slice length: length needs to be at least 32, got %v", len(dst)))
+	}
+
+	putX(dst[:PubKeySizeMarshalled], &pub.point)
+}
+
+// SetBytes sets the public key from the given 32 byte x-only encoding. It will
+// return an error if the bytes represent a number greater than or equal to P,
+// or if there is no curve point with the given x coordinate.
+//
+// Panics: If the byte slice has length less than 32, this function will panic.
+func (pub *PubKey) SetBytes(bs []byte) error {
+	if len(bs) < PubKeySizeMarshalled {
+		panic(fmt.Sprintf("invalid slice length: length needs to be at least 32, got %v", len(bs)))
+	}
+
+	var x secp256k1.Fp
+	if x.SetB32(bs[:PubKeySizeMarshalled]) {
+		*pub = PubKey{}
+		return errors.New("invalid public key data")
+	}
+
+	// A leading byte with the lowest bit unset selects the even y coordinate.
+	var compressed [secp256k1.PointSizeMarshalled]byte
+	compressed[0] = 0x02
+	copy(compressed[1:], bs[:PubKeySizeMarshalled])
+	if err := pub.point.SetBytes(compressed[:]); err != nil {
+		*pub = PubKey{}
+		return errors.New("invalid public key data")
+	}
+	return nil
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (pub PubKey) SizeHint() int { return PubKeySizeMarshalled }
+
+// Marshal implements the surge.Marshaler interface.
+func (pub PubKey) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	if len(buf) < PubKeySizeMarshalled || rem < PubKeySizeMarshalled {
+		return buf, rem, surge.ErrUnexpectedEndOfBuffer
+	}
+
+	pub.PutBytes(buf[:PubKeySizeMarshalled])
+
+	return buf[PubKeySizeMarshalled:], rem - PubKeySizeMarshalled, nil
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (pub *PubKey) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	if len(buf) < PubKeySizeMarshalled || rem < secp256k1.PointSize {
+		return buf, rem, surge.ErrUnexpectedEndOfBuffer
+	}
+
+	err := pub.SetBytes(buf[:PubKeySizeMarshalled])
+	return buf[PubKeySizeMarshalled:], rem - secp256k1.PointSize, err
+}
+
+// Signature represents a BIP-340 Schnorr signature. R is the x coordinate of
+// the nonce point, which always has an even y coordinate.
+type Signature struct {
+	R secp256k1.Fp
+	S secp256k1.Fn
+}
+
+// Sign signs the given message with the given private key. The auxiliary
+// randomness is mixed into the derivation of the nonce to protect against side
+// channel attacks; it should be freshly generated random bytes for each
+// signature, but signing remains secure if it is all zeros. An error is
+// returned if the private key is zero.
+func Sign(priv *secp256k1.Fn, msg [32]byte, aux [32]byte) (Signature, error) {
+	if priv.IsZero() {
+		return Signature{}, errors.New("invalid private key: zero")
+	}
+
+	var d, k, e, s secp256k1.Fn
+	var P, R secp256k1.Point
+	var t, px, rx [32]byte
+	defer func() {
+		d.Clear()
+		k.Clear()
+		t = [32]byte{}
+	}()
+
+	// Negate the private key if needed so that the public key has an even y
+	// coordinate.
+	d = *priv
+	P.BaseExp(&d)
+	if !P.HasEvenY() {
+		d.Negate(&d)
+		P.Negate(&P)
+	}
+	putX(px[:], &P)
+
+	auxHash := taggedHash(&tagAux, aux[:])
+	d.PutB32(t[:])
+	for i := range t {
+		t[i] ^= auxHash[i]
+	}
+
+	nonce := taggedHash(&tagNonce, t[:], px[:], msg[:])
+	k.SetB32(nonce[:])
+	if k.IsZero() {
+		// This happens with negligible probability.
+		return Signature{}, errors.New("invalid nonce: zero")
+	}
+
+	R.BaseExp(&k)
+	if !R.HasEvenY() {
+		k.Negate(&k)
+		R.Negate(&R)
+	}
+	putX(rx[:], &R)
+
+	challenge := taggedHash(&tagChallenge, rx[:], px[:], msg[:])
+	e.SetB32(challenge[:])
+
+	// s = k + e*d
+	s.Mul(&e, &d)
+	s.Add(&s, &k)
+
+	var sig Signature
+	sig.R.SetB32(rx[:])
+	sig.S = s
+	return sig, nil
+}
+
+// Verify returns true if the given signature is a valid signature of the given
+// message for the given public key, and false otherwise.
+func Verify(pub *PubKey, msg [32]byte, sig *Signature) bool {
+	var px, rx [32]byte
+	if !putValidX(px[:], &pub.point) {
+		return false
+	}
+	sig.R.PutB32(rx[:])
+
+	var e secp256k1.Fn
+	challenge := taggedHash(&tagChallenge, rx[:], px[:], msg[:])
+	e.SetB32(challenge[:])
+	e.Negate(&e)
+
+	// R = s*G - e*P
+	var R secp256k1.Point
+	R.DoubleBaseExpVar(&sig.S, &pub.point, &e)
+	if R.IsInfinity() || !R.HasEvenY() {
+		return false
+	}
+
+	x, _, _ := R.XY()
+	return x.Eq(&sig.R)
+}
+
+// putX stores the big endian bytes of the x coordinate of the given point,
+// which must not be the point at infinity, in the given byte slice.
+func putX(dst []byte, p *secp256k1.Point) {
+	x, _, err := p.XY()
+	if err != nil {
+		panic(fmt.Sprintf("unexpected point at infinity: %v", err))
+	}
+	x.PutB32(dst)
+}
+
+// putValidX is the same as putX, but returns false instead of panicking if the
+// point is the point at infinity or is not on the curve. This avoids the
+// additional field inversion that checking with IsOnCurve would require.
+func putValidX(dst []byte, p *secp256k1.Point) bool {
+	x, y, err := p.XY()
+	if err != nil {
+		return false
+	}
+
+	// y^2 = x^3 + 7
+	var lhs, rhs secp256k1.Fp
+	seven := secp256k1.NewFpFromU64(7)
+	lhs.Sqr(&y)
+	rhs.Sqr(&x)
+	rhs.Mul(&rhs, &x)
+	rhs.Add(&rhs, &seven)
+	if !lhs.Eq(&rhs) {
+		return false
+	}
+
+	x.PutB32(dst)
+	return true
+}
+
+// PutBytes stores the signature in the given byte slice in the 64 byte form
+// defined by BIP-340, which is the big endian bytes of R followed by the big
+// endian bytes of S.
+//
+// Panics: If the byte slice has length less than 64, this function will panic.
+func (sig *Signature) PutBytes(dst []byte) {
+	if len(dst) < SignatureSizeMarshalled {
+		panic(fmt.Sprintf("invalid slice length: length needs to be at least 64, got %v", len(dst)))
+	}
+
+	sig.R.PutB32(dst[:32])
+	sig.S.PutB32(dst[32:64])
+}
+
+// SetBytes sets the signature from the given byte slice in the 64 byte form
+// defined by BIP-340. It will return an error if R is greater than or equal to
+// P, or if S is greater than or equal to N.
+//
+// Panics: If the byte slice has length less than 64, this function will panic.
+func (sig *Signature) SetBytes(bs []byte) error {
+	if len(bs) < SignatureSizeMarshalled {
+		panic(fmt.Sprintf("invalid slice length: length needs to be at least 64, got %v", len(bs)))
+	}
+
+	overflowR := sig.R.SetB32(bs[:32])
+	overflowS := sig.S.SetB32(bs[32:64])
+	if overflowR || overflowS {
+		*sig = Signature{}
+		return errors.New("invalid signature data")
+	}
+	return nil
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (sig Signature) SizeHint() int { return SignatureSizeMarshalled }
+
+// Marshal implements the surge.Marshaler interface.
+func (sig Signature) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := sig.R.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return sig.S.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface. As with SetBytes, an
+// error is returned if R is greater than or equal to P, or if S is greater
+// than or equal to N, since BIP-340 requires such signatures to be rejected
+// rather than reduced.
+func (sig *Signature) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := sig.R.UnmarshalStrict(buf, rem)
+	if err != nil {
+		*sig = Signature{}
+		return buf, rem, err
+	}
+	buf, rem, err = sig.S.UnmarshalStrict(buf, rem)
+	if err != nil {
+		*sig = Signature{}
+		return buf, rem, err
+	}
+	return buf, rem, nil
+}
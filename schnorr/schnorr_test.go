@@ -0,0 +1,493 @@
+package schnorr_test
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/renproject/secp256k1"
+	. "github.com/renproject/secp256k1/schnorr"
+)
+
+var _ = Describe("Schnorr", func() {
+	trials := 100
+
+	random32 := func() [32]byte {
+		var bs [32]byte
+		if _, err := rand.Read(bs[:]); err != nil {
+			panic(err)
+		}
+		return bs
+	}
+
+	randomKeyPair := func() (secp256k1.Fn, PubKey) {
+		priv := secp256k1.RandomFn()
+		return priv, NewPubKey(&priv)
+	}
+
+	decodeHex := func(str string) []byte {
+		bs, err := hex.DecodeString(str)
+		if err != nil {
+			panic(err)
+		}
+		return bs
+	}
+
+	decode32 := func(str string) [32]byte {
+		var arr [32]byte
+		bs := decodeHex(str)
+		if len(bs) != 32 {
+			panic("invalid hex length")
+		}
+		copy(arr[:], bs)
+		return arr
+	}
+
+	Context("when using the BIP-340 test vectors", func() {
+		It("should produce the test vector public keys and signatures", func() {
+			vectors := []struct {
+				priv, pub, aux, msg, sig string
+			}{
+				{
+					"0000000000000000000000000000000000000000000000000000000000000003",
+					"F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+					"0000000000000000000000000000000000000000000000000000000000000000",
+					"0000000000000000000000000000000000000000000000000000000000000000",
+					"E907831F80848D1069A5371B402410364BDF1C5F8307B0084C55F1CE2DCA821525F66A4A85EA8B71E482A74F382D2CE5EBEEE8FDB2172F477DF4900D310536C0",
+				},
+				{
+					"B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF",
+					"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+					"0000000000000000000000000000000000000000000000000000000000000001",
+					"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+					"6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE33418906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A",
+				},
+				{
+					"C90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74020BBEA63B14E5C9",
+					"DD308AFEC5777E13121FA72B9CC1B7CC0139715309B086C960E18FD969774EB8",
+					"C87AA53824B4D7AE2EB035A2B5BBBCCC080E76CDC6D1692C4B0B62D798E6D906",
+					"7E2D58D8B3BCDF1ABADEC7829054F90DDA9805AAB56C77333024B9D0A508B75C",
+					"5831AAEED7B44BB74E5EAB94BA9D4294C49BCF2A60728D8B4C200F50DD313C1BAB745879A5AD954A72C45A91C3A51D3C7ADEA98D82F8481E0E1E03674A6F3FB7",
+				},
+				{
+					"0B432B2677937381AEF05BB02A66ECD012773062CF3FA2549E44F58ED2401710",
+					"25D1DFF95105F5253C4022F628A996AD3A0D95FBF21D468A1B33F8C160D8F517",
+					"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF",
+					"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF",
+					"7EB0509757E246F19449885651611CB965ECC1A187DD51B64FDA1EDC9637D5EC97582B9CB13DB3933705B32BA982AF5AF25FD78881EBB32771FC5922EFC66EA3",
+				},
+			}
+
+			var pubBytes [PubKeySizeMarshalled]byte
+			var sigBytes [SignatureSizeMarshalled]byte
+			for _, vector := range vectors {
+				var priv secp256k1.Fn
+				privBytes := decode32(vector.priv)
+				priv.SetB32(privBytes[:])
+
+				pub := NewPubKey(&priv)
+				pub.PutBytes(pubBytes[:])
+				Expect(pubBytes[:]).To(Equal(decodeHex(vector.pub)))
+
+				sig, err := Sign(&priv, decode32(vector.msg), decode32(vector.aux))
+				Expect(err).ToNot(HaveOccurred())
+				sig.PutBytes(sigBytes[:])
+				Expect(sigBytes[:]).To(Equal(decodeHex(vector.sig)))
+
+				Expect(Verify(&pub, decode32(vector.msg), &sig)).To(BeTrue())
+			}
+		})
+
+		It("should verify the test vector signatures", func() {
+			// Test vectors 4 to 14. Decoding errors count as verification
+			// failures, as in the reference implementation.
+			pub := "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659"
+			msg := "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89"
+			vectors := []struct {
+				pub, msg, sig string
+				valid         bool
+			}{
+				{
+					"D69C3509BB99E412E68B0FE8544E72837DFA30746D8BE2AA65975F29D22DC7B9",
+					"4DF3C3F68FCC83B27E9D42C90431A72499F17875C81A599B566C9889B9696703",
+					"00000000000000000000003B78CE563F89A0ED9414F5AA28AD0D96D6795F9C6376AFB1548AF603B3EB45C9F8207DEE1060CB71C04E80F593060B07D28308D7F4",
+					true,
+				},
+				{
+					// The public key is not on the curve.
+					"EEFDEA4CDB677750A420FEE807EACF21EB9898AE79B9768766E4FAA04A2D4A34",
+					msg,
+					"6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E17776969E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B",
+					false,
+				},
+				{
+					// The nonce point has an odd y coordinate.
+					pub,
+					msg,
+					"FFF97BD5755EEEA420453A14355235D382F6472F8568A18B2F057A14602975563CC27944640AC607CD107AE10923D9EF7A73C643E166BE5EBEAFA34B1AC553E2",
+					false,
+				},
+				{
+					// The signature is for the negated message.
+					pub,
+					msg,
+					"1FA62E331EDBC21C394792D2AB1100A7B432B013DF3F6FF4F99FCB33E0E1515F28890B3EDB6E7189B630448B515CE4F8622A954CFE545735AAEA5134FCCDB2BD",
+					false,
+				},
+				{
+					// S has been negated.
+					pub,
+					msg,
+					"6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E177769961764B3AA9B2FFCB6EF947B6887A226E8D7C93E00C5ED0C1834FF0D0C2E6DA6",
+					false,
+				},
+				{
+					// s*G - e*P is the point at infinity and R is 0.
+					pub,
+					msg,
+					"0000000000000000000000000000000000000000000000000000000000000000123DDA8328AF9C23A94C1FEECFD123BA4FB73476F0D594DCB65C6425BD186051",
+					false,
+				},
+				{
+					// s*G - e*P is the point at infinity and R is 1.
+					pub,
+					msg,
+					"00000000000000000000000000000000000000000000000000000000000000017615FBAF5AE28864013C099742DEADB4DBA87F11AC6754F93780D5A1837CF197",
+					false,
+				},
+				{
+					// R is not the x coordinate of a curve point.
+					pub,
+					msg,
+					"4A298DACAE57395A15D0795DDBFD1DCB564DA82B0F269BC70A74F8220429BA1D69E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B",
+					false,
+				},
+				{
+					// R is equal to P.
+					pub,
+					msg,
+					"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F69E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B",
+					false,
+				},
+				{
+					// S is equal to N.
+					pub,
+					msg,
+					"6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E177769FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141",
+					false,
+				},
+				{
+					// The public key is greater than P.
+					"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC30",
+					msg,
+					"6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E17776969E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B",
+					false,
+				},
+			}
+
+			verifyBytes := func(pubBytes, sigBytes []byte, msg [32]byte) bool {
+				var pub PubKey
+				var sig Signature
+				if pub.SetBytes(pubBytes) != nil || sig.SetBytes(sigBytes) != nil {
+					return false
+				}
+				return Verify(&pub, msg, &sig)
+			}
+
+			verifySurge := func(pubBytes, sigBytes []byte, msg [32]byte) bool {
+				var pub PubKey
+				var sig Signature
+				if _, _, err := pub.Unmarshal(pubBytes, secp256k1.PointSize); err != nil {
+					return false
+				}
+				if _, _, err := sig.Unmarshal(sigBytes, secp256k1.FpSize+secp256k1.FnSize); err != nil {
+					return false
+				}
+				return Verify(&pub, msg, &sig)
+			}
+
+			for _, vector := range vectors {
+				pubBytes, sigBytes := decodeHex(vector.pub), decodeHex(vector.sig)
+				Expect(verifyBytes(pubBytes, sigBytes, decode32(vector.msg))).To(Equal(vector.valid))
+				Expect(verifySurge(pubBytes, sigBytes, decode32(vector.msg))).To(Equal(vector.valid))
+			}
+		})
+
+		It("should reject public keys that are not on the curve", func() {
+			var pub PubKey
+			bs := decodeHex("EEFDEA4CDB677750A420FEE807EACF21EB9898AE79B9768766E4FAA04A2D4A34")
+			Expect(pub.SetBytes(bs)).ToNot(Succeed())
+		})
+	})
+
+	Context("when signing", func() {
+		It("should be deterministic for the same auxiliary randomness", func() {
+			for i := 0; i < trials; i++ {
+				priv, _ := randomKeyPair()
+				msg, aux := random32(), random32()
+
+				sig1, err := Sign(&priv, msg, aux)
+				Expect(err).ToNot(HaveOccurred())
+				sig2, err := Sign(&priv, msg, aux)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(sig1.R.Eq(&sig2.R)).To(BeTrue())
+				Expect(sig1.S.Eq(&sig2.S)).To(BeTrue())
+			}
+		})
+
+		It("should produce different valid signatures for different auxiliary randomness", func() {
+			for i := 0; i < trials; i++ {
+				priv, pub := randomKeyPair()
+				msg := random32()
+
+				sig1, err := Sign(&priv, msg, random32())
+				Expect(err).ToNot(HaveOccurred())
+				sig2, err := Sign(&priv, msg, random32())
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(sig1.R.Eq(&sig2.R)).To(BeFalse())
+				Expect(Verify(&pub, msg, &sig1)).To(BeTrue())
+				Expect(Verify(&pub, msg, &sig2)).To(BeTrue())
+			}
+		})
+
+		It("should produce the same signature for a private key and its negation", func() {
+			var negated secp256k1.Fn
+			for i := 0; i < trials; i++ {
+				priv, pub := randomKeyPair()
+				negated.Negate(&priv)
+				msg, aux := random32(), random32()
+
+				negatedPub := NewPubKey(&negated)
+				Expect(negatedPub.Eq(&pub)).To(BeTrue())
+
+				sig1, err := Sign(&priv, msg, aux)
+				Expect(err).ToNot(HaveOccurred())
+				sig2, err := Sign(&negated, msg, aux)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(sig1.R.Eq(&sig2.R)).To(BeTrue())
+				Expect(sig1.S.Eq(&sig2.S)).To(BeTrue())
+			}
+		})
+
+		It("should return an error for the zero private key", func() {
+			zero := secp256k1.Fn{}
+			_, err := Sign(&zero, random32(), random32())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when verifying", func() {
+		It("should accept valid signatures", func() {
+			for i := 0; i < trials; i++ {
+				priv, pub := randomKeyPair()
+				msg := random32()
+
+				sig, err := Sign(&priv, msg, random32())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(Verify(&pub, msg, &sig)).To(BeTrue())
+			}
+		})
+
+		It("should reject signatures for a different message or key", func() {
+			for i := 0; i < trials; i++ {
+				priv, pub := randomKeyPair()
+				_, otherPub := randomKeyPair()
+				msg := random32()
+
+				sig, err := Sign(&priv, msg, random32())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(Verify(&pub, random32(), &sig)).To(BeFalse())
+				Expect(Verify(&otherPub, msg, &sig)).To(BeFalse())
+			}
+		})
+
+		It("should reject modified signatures", func() {
+			oneFp := secp256k1.NewFpFromU64(1)
+			oneFn := secp256k1.NewFnFromU16(1)
+			for i := 0; i < trials; i++ {
+				priv, pub := randomKeyPair()
+				msg := random32()
+
+				sig, err := Sign(&priv, msg, random32())
+				Expect(err).ToNot(HaveOccurred())
+
+				modified := sig
+				modified.R.Add(&modified.R, &oneFp)
+				Expect(Verify(&pub, msg, &modified)).To(BeFalse())
+
+				modified = sig
+				modified.S.Add(&modified.S, &oneFn)
+				Expect(Verify(&pub, msg, &modified)).To(BeFalse())
+			}
+		})
+
+		It("should reject the zero public key", func() {
+			var pub PubKey
+			priv, _ := randomKeyPair()
+			msg := random32()
+			sig, err := Sign(&priv, msg, random32())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(Verify(&pub, msg, &sig)).To(BeFalse())
+		})
+	})
+
+	Context("when using x-only public keys", func() {
+		It("should always have an even y coordinate", func() {
+			for i := 0; i < trials; i++ {
+				_, pub := randomKeyPair()
+				p := pub.Point()
+				Expect(p.HasEvenY()).To(BeTrue())
+			}
+		})
+
+		It("should be the same for a point and its negation", func() {
+			var negated secp256k1.Point
+			for i := 0; i < trials; i++ {
+				p := secp256k1.RandomPoint()
+				negated.Negate(&p)
+
+				pub1 := NewPubKeyFromPoint(&p)
+				pub2 := NewPubKeyFromPoint(&negated)
+				Expect(pub1.Eq(&pub2)).To(BeTrue())
+			}
+		})
+
+		It("should be equal after converting to and from bytes", func() {
+			var bs [PubKeySizeMarshalled]byte
+			var after PubKey
+			for i := 0; i < trials; i++ {
+				_, before := randomKeyPair()
+				before.PutBytes(bs[:])
+				Expect(after.SetBytes(bs[:])).To(Succeed())
+				Expect(after.Eq(&before)).To(BeTrue())
+			}
+		})
+
+		It("should return an error when the x coordinate is out of range", func() {
+			var pub PubKey
+			bs := decodeHex("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC30")
+			Expect(pub.SetBytes(bs)).ToNot(Succeed())
+		})
+
+		It("should be equal after marshaling and unmarshaling with surge", func() {
+			var bs [PubKeySizeMarshalled]byte
+			var after PubKey
+			for i := 0; i < trials; i++ {
+				_, before := randomKeyPair()
+
+				tail, rem, err := before.Marshal(bs[:], before.SizeHint())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rem).To(Equal(0))
+				Expect(len(tail)).To(Equal(0))
+
+				tail, rem, err = after.Unmarshal(bs[:], secp256k1.PointSize)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rem).To(Equal(0))
+				Expect(len(tail)).To(Equal(0))
+
+				Expect(after.Eq(&before)).To(BeTrue())
+			}
+		})
+
+		It("should panic when the slice length is too small", func() {
+			var pub PubKey
+			var bs [PubKeySizeMarshalled - 1]byte
+			for i := 0; i < PubKeySizeMarshalled-1; i++ {
+				Expect(func() { pub.PutBytes(bs[:i]) }).To(Panic())
+				Expect(func() { pub.SetBytes(bs[:i]) }).To(Panic())
+			}
+		})
+	})
+
+	Context("when marshalling signatures", func() {
+		It("should be equal after converting to and from bytes", func() {
+			var bs [SignatureSizeMarshalled]byte
+			var after Signature
+			for i := 0; i < trials; i++ {
+				priv, _ := randomKeyPair()
+				before, err := Sign(&priv, random32(), random32())
+				Expect(err).ToNot(HaveOccurred())
+
+				before.PutBytes(bs[:])
+				Expect(after.SetBytes(bs[:])).To(Succeed())
+				Expect(after.R.Eq(&before.R)).To(BeTrue())
+				Expect(after.S.Eq(&before.S)).To(BeTrue())
+			}
+		})
+
+		It("should return an error when decoding values that are out of range", func() {
+			var sig Signature
+			bs := [SignatureSizeMarshalled]byte{}
+
+			// R is equal to P.
+			copy(bs[:32], decodeHex("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F"))
+			Expect(sig.SetBytes(bs[:])).ToNot(Succeed())
+			_, _, err := sig.Unmarshal(bs[:], secp256k1.FpSize+secp256k1.FnSize)
+			Expect(err).To(HaveOccurred())
+
+			// S is equal to N.
+			bs = [SignatureSizeMarshalled]byte{}
+			copy(bs[32:], decodeHex("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141"))
+			Expect(sig.SetBytes(bs[:])).ToNot(Succeed())
+			_, _, err = sig.Unmarshal(bs[:], secp256k1.FpSize+secp256k1.FnSize)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should be equal after marshaling and unmarshaling with surge", func() {
+			var bs [SignatureSizeMarshalled]byte
+			var after Signature
+			for i := 0; i < trials; i++ {
+				priv, _ := randomKeyPair()
+				before, err := Sign(&priv, random32(), random32())
+				Expect(err).ToNot(HaveOccurred())
+
+				tail, rem, err := before.Marshal(bs[:], before.SizeHint())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rem).To(Equal(0))
+				Expect(len(tail)).To(Equal(0))
+
+				tail, rem, err = after.Unmarshal(bs[:], secp256k1.FpSize+secp256k1.FnSize)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rem).To(Equal(0))
+				Expect(len(tail)).To(Equal(0))
+
+				Expect(after.R.Eq(&before.R)).To(BeTrue())
+				Expect(after.S.Eq(&before.S)).To(BeTrue())
+			}
+		})
+
+		It("should panic when the slice length is too small", func() {
+			var sig Signature
+			var bs [SignatureSizeMarshalled - 1]byte
+			for i := 0; i < SignatureSizeMarshalled-1; i++ {
+				Expect(func() { sig.PutBytes(bs[:i]) }).To(Panic())
+				Expect(func() { sig.SetBytes(bs[:i]) }).To(Panic())
+			}
+		})
+	})
+})
+
+func BenchmarkSign(b *testing.B) {
+	priv := secp256k1.RandomFn()
+	msg, aux := [32]byte{}, [32]byte{}
+
+	for i := 0; i < b.N; i++ {
+		_, _ = Sign(&priv, msg, aux)
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	priv := secp256k1.RandomFn()
+	pub := NewPubKey(&priv)
+	msg, aux := [32]byte{}, [32]byte{}
+	sig, _ := Sign(&priv, msg, aux)
+
+	for i := 0; i < b.N; i++ {
+		_ = Verify(&pub, msg, &sig)
+	}
+}
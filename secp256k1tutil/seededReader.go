@@ -0,0 +1,53 @@
+package secp256k1tutil
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+)
+
+type seededReader struct {
+	key     [32]byte
+	counter uint64
+	block   [32]byte
+	off     int
+}
+
+// NewSeededReader returns a reader that produces a deterministic stream of
+// pseudorandom bytes determined by the given seed, so two readers with the
+// same seed produce the same bytes. The stream is the concatenation of the 32
+// byte blocks SHA256(SHA256(seed) || counter) for counter = 0, 1, 2, ...,
+// where the seed and the counter are both encoded as 8 byte big endian
+// integers. The reader is not safe for concurrent use, and it must not be used
+// as a source of randomness for secret values.
+func NewSeededReader(seed int64) io.Reader {
+	var bs [8]byte
+	binary.BigEndian.PutUint64(bs[:], uint64(seed))
+
+	r := &seededReader{key: sha256.Sum256(bs[:])}
+	r.off = len(r.block)
+	return r
+}
+
+func (r *seededReader) Read(b []byte) (int, error) {
+	n := 0
+	for n < len(b) {
+		if r.off == len(r.block) {
+			r.next()
+		}
+		c := copy(b[n:], r.block[r.off:])
+		r.off += c
+		n += c
+	}
+	return n, nil
+}
+
+func (r *seededReader) next() {
+	var bs [40]byte
+	copy(bs[:32], r.key[:])
+	binary.BigEndian.PutUint64(bs[32:], r.counter)
+
+	r.block = sha256.Sum256(bs[:])
+	r.counter++
+	r.off = 0
+}
@@ -0,0 +1,136 @@
+package shamir
+
+import (
+	"github.com/renproject/secp256k1"
+	"github.com/renproject/surge"
+)
+
+// Commitment represents a commitment to a sharing polynomial, which is a list
+// of curve points with one point for each coefficient of the polynomial, in
+// increasing order of degree. For a Feldman commitment the ith point is a_i*G,
+// and for a Pedersen commitment it is a_i*G + b_i*H, where the a_i are the
+// coefficients of the sharing polynomial and the b_i are the coefficients of
+// the blinding polynomial.
+type Commitment []secp256k1.Point
+
+// Eq returns true if the two commitments are equal, and false otherwise.
+func (c Commitment) Eq(other Commitment) bool {
+	if len(c) != len(other) {
+		return false
+	}
+	for i := range c {
+		if !c[i].Eq(&other[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Threshold returns the number of shares that are needed to reconstruct the
+// secret of the sharing that the commitment corresponds to.
+func (c Commitment) Threshold() int {
+	return len(c)
+}
+
+// Add computes the sum of the two commitments and stores the result in the
+// receiver. The result is a commitment to the sum of the two sharing
+// polynomials. If the commitments have different lengths, the result has the
+// length of the longer of the two.
+func (c *Commitment) Add(a, b Commitment) {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+
+	if cap(*c) < len(a) {
+		*c = make(Commitment, len(a))
+	}
+	*c = (*c)[:len(a)]
+
+	for i := range b {
+		(*c)[i].Add(&a[i], &b[i])
+	}
+	copy((*c)[len(b):], a[len(b):])
+}
+
+// Scale computes the scalar multiplication of the given commitment by the given
+// scalar and stores the result in the receiver. The result is a commitment to
+// the sharing polynomial multiplied by the scalar.
+func (c *Commitment) Scale(a Commitment, scalar *secp256k1.Fn) {
+	if cap(*c) < len(a) {
+		*c = make(Commitment, len(a))
+	}
+	*c = (*c)[:len(a)]
+
+	for i := range a {
+		(*c)[i].ScaleExt(&a[i], scalar)
+	}
+}
+
+// Evaluate computes the value of the committed polynomial at the given index
+// in the exponent and stores the result in the given point. For a Feldman
+// commitment this is v*G, where v is the value of the share with that index.
+func (c Commitment) Evaluate(dst *secp256k1.Point, index *secp256k1.Fn) {
+	// c_0 + index*c_1 + ... + index^{k-1}*c_{k-1}
+	powers := make([]secp256k1.Fn, len(c))
+	if len(powers) > 0 {
+		powers[0] = secp256k1.NewFnFromU16(1)
+	}
+	for i := 1; i < len(powers); i++ {
+		powers[i].Mul(&powers[i-1], index)
+	}
+
+	dst.MultiScaleVar(c, powers)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (c Commitment) SizeHint() int {
+	return surge.SizeHintU32 + secp256k1.PointSizeMarshalled*len(c)
+}
+
+// Marshal implements the surge.Marshaler interface.
+func (c Commitment) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := surge.MarshalU32(uint32(len(c)), buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+
+	for i := range c {
+		buf, rem, err = c[i].Marshal(buf, rem)
+		if err != nil {
+			return buf, rem, err
+		}
+	}
+
+	return buf, rem, nil
+}
+
+// Unmarshal implements the surge.Unmarshaler interface. The points are decoded
+// strictly with Point.UnmarshalStrict, so that each commitment has only one
+// encoding.
+func (c *Commitment) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	var l uint32
+	buf, rem, err := surge.UnmarshalU32(&l, buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+
+	// Check the length before allocating so that a malicious length prefix
+	// can not cause a large allocation.
+	if rem < int(l)*secp256k1.PointSize {
+		return buf, rem, surge.ErrLengthOverflow
+	}
+
+	if cap(*c) < int(l) {
+		*c = make(Commitment, l)
+	}
+	*c = (*c)[:l]
+
+	for i := range *c {
+		buf, rem, err = (*c)[i].UnmarshalStrict(buf, rem)
+		if err != nil {
+			return buf, rem, err
+		}
+	}
+
+	return buf, rem, nil
+}
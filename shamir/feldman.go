@@ -0,0 +1,39 @@
+package shamir
+
+import (
+	"github.com/renproject/secp256k1"
+)
+
+// VShareSecret creates a k-out-of-n sharing of the given secret in the same
+// way as ShareSecret, and additionally returns the Feldman commitment to the
+// sharing polynomial, which can be used to verify each of the shares with
+// IsValid.
+func VShareSecret(secret secp256k1.Fn, indices []secp256k1.Fn, k int) ([]Share, Commitment, error) {
+	shares, coeffs, err := shareSecret(secret, indices, k)
+	defer clearFns(coeffs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	commitment := make(Commitment, k)
+	for i := range coeffs {
+		commitment[i].BaseExp(&coeffs[i])
+	}
+
+	return shares, commitment, nil
+}
+
+// IsValid returns true if the given share is consistent with the given
+// commitment, and false otherwise. That is, it checks that v*G is equal to the
+// committed polynomial evaluated in the exponent at the index of the share,
+// where v is the value of the share.
+func IsValid(c Commitment, share *Share) bool {
+	if len(c) == 0 {
+		return false
+	}
+
+	var expected, actual secp256k1.Point
+	c.Evaluate(&expected, &share.Index)
+	actual.BaseExp(&share.Value)
+	return actual.Eq(&expected)
+}
@@ -0,0 +1,250 @@
+package shamir_test
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/renproject/secp256k1"
+	. "github.com/renproject/secp256k1/shamir"
+	"github.com/renproject/surge"
+)
+
+var _ = Describe("Feldman verifiable secret sharing", func() {
+	trials := 20
+	n := 20
+
+	Context("when sharing", func() {
+		It("should produce shares that are valid for the commitment", func() {
+			for i := 0; i < trials; i++ {
+				k := rand.Intn(n) + 1
+				shares, c, err := VShareSecret(secp256k1.RandomFn(), randomIndices(n), k)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(c.Threshold()).To(Equal(k))
+
+				for j := range shares {
+					Expect(IsValid(c, &shares[j])).To(BeTrue())
+				}
+			}
+		})
+
+		It("should produce shares that open to the secret", func() {
+			for i := 0; i < trials; i++ {
+				k := rand.Intn(n) + 1
+				secret := secp256k1.RandomFn()
+				shares, _, err := VShareSecret(secret, randomIndices(n), k)
+				Expect(err).ToNot(HaveOccurred())
+
+				opened, err := Open(randomSubset(shares, k))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(opened.Eq(&secret)).To(BeTrue())
+			}
+		})
+
+		It("should commit to the secret in the exponent", func() {
+			var expected secp256k1.Point
+			for i := 0; i < trials; i++ {
+				secret := secp256k1.RandomFn()
+				_, c, err := VShareSecret(secret, randomIndices(n), n/2)
+				Expect(err).ToNot(HaveOccurred())
+
+				expected.BaseExp(&secret)
+				Expect(c[0].Eq(&expected)).To(BeTrue())
+			}
+		})
+
+		It("should produce valid shares of a zero secret", func() {
+			for i := 0; i < trials; i++ {
+				// With k = 1 the commitment is a single point at infinity.
+				k := i%n + 1
+				shares, c, err := VShareSecret(secp256k1.Fn{}, randomIndices(n), k)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(c[0].IsInfinity()).To(BeTrue())
+
+				for j := range shares {
+					Expect(IsValid(c, &shares[j])).To(BeTrue())
+				}
+			}
+		})
+
+		It("should return an error for invalid arguments", func() {
+			indices := randomIndices(n)
+			_, _, err := VShareSecret(secp256k1.RandomFn(), indices, 0)
+			Expect(err).To(HaveOccurred())
+			_, _, err = VShareSecret(secp256k1.RandomFn(), indices, n+1)
+			Expect(err).To(HaveOccurred())
+
+			indices[1] = indices[0]
+			_, _, err = VShareSecret(secp256k1.RandomFn(), indices, n/2)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when verifying", func() {
+		It("should reject shares with a modified value or index", func() {
+			one := secp256k1.NewFnFromU16(1)
+			for i := 0; i < trials; i++ {
+				k := rand.Intn(n) + 1
+				shares, c, err := VShareSecret(secp256k1.RandomFn(), randomIndices(n), k)
+				Expect(err).ToNot(HaveOccurred())
+
+				j := rand.Intn(n)
+				modified := shares[j]
+				modified.Value.Add(&modified.Value, &one)
+				Expect(IsValid(c, &modified)).To(BeFalse())
+
+				// A constant polynomial has the same value at every index.
+				if k > 1 {
+					modified = shares[j]
+					modified.Index.Add(&modified.Index, &one)
+					Expect(IsValid(c, &modified)).To(BeFalse())
+				}
+			}
+		})
+
+		It("should reject shares for a different commitment", func() {
+			indices := randomIndices(n)
+			for i := 0; i < trials; i++ {
+				shares, _, err := VShareSecret(secp256k1.RandomFn(), indices, n/2)
+				Expect(err).ToNot(HaveOccurred())
+				_, c, err := VShareSecret(secp256k1.RandomFn(), indices, n/2)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(IsValid(c, &shares[rand.Intn(n)])).To(BeFalse())
+			}
+		})
+
+		It("should reject all shares for the empty commitment", func() {
+			shares, _, err := VShareSecret(secp256k1.RandomFn(), randomIndices(n), n/2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(IsValid(Commitment{}, &shares[0])).To(BeFalse())
+		})
+	})
+
+	Context("when adding", func() {
+		It("should produce valid shares of the sum of the secrets", func() {
+			for i := 0; i < trials; i++ {
+				k1, k2 := rand.Intn(n)+1, rand.Intn(n)+1
+				indices := randomIndices(n)
+				secret1, secret2 := secp256k1.RandomFn(), secp256k1.RandomFn()
+
+				shares1, c1, err := VShareSecret(secret1, indices, k1)
+				Expect(err).ToNot(HaveOccurred())
+				shares2, c2, err := VShareSecret(secret2, indices, k2)
+				Expect(err).ToNot(HaveOccurred())
+
+				var c Commitment
+				c.Add(c1, c2)
+				k := k1
+				if k2 > k {
+					k = k2
+				}
+				Expect(c.Threshold()).To(Equal(k))
+
+				sum := make([]Share, n)
+				for j := range sum {
+					sum[j].Add(&shares1[j], &shares2[j])
+					Expect(IsValid(c, &sum[j])).To(BeTrue())
+				}
+
+				var expected secp256k1.Fn
+				expected.Add(&secret1, &secret2)
+				opened, err := Open(randomSubset(sum, k))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(opened.Eq(&expected)).To(BeTrue())
+			}
+		})
+
+		It("should compute correctly when the receiver is an argument", func() {
+			for i := 0; i < trials; i++ {
+				_, c1, err := VShareSecret(secp256k1.RandomFn(), randomIndices(n), n/2)
+				Expect(err).ToNot(HaveOccurred())
+				_, c2, err := VShareSecret(secp256k1.RandomFn(), randomIndices(n), n/2)
+				Expect(err).ToNot(HaveOccurred())
+
+				var expected Commitment
+				expected.Add(c1, c2)
+				c1.Add(c1, c2)
+				Expect(c1.Eq(expected)).To(BeTrue())
+			}
+		})
+
+		It("should panic when adding shares with different indices", func() {
+			var share Share
+			a := NewShare(secp256k1.RandomFn(), secp256k1.RandomFn())
+			b := NewShare(secp256k1.RandomFn(), secp256k1.RandomFn())
+			Expect(func() { share.Add(&a, &b) }).To(Panic())
+		})
+	})
+
+	Context("when marshalling", func() {
+		It("should be equal after marshaling and unmarshaling with surge", func() {
+			for i := 0; i < trials; i++ {
+				_, before, err := VShareSecret(secp256k1.RandomFn(), randomIndices(n), rand.Intn(n)+1)
+				Expect(err).ToNot(HaveOccurred())
+
+				bs := make([]byte, before.SizeHint())
+				tail, rem, err := before.Marshal(bs, before.SizeHint())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rem).To(Equal(0))
+				Expect(len(tail)).To(Equal(0))
+
+				var after Commitment
+				tail, _, err = after.Unmarshal(bs, surge.MaxBytes)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(len(tail)).To(Equal(0))
+				Expect(after.Eq(before)).To(BeTrue())
+			}
+		})
+
+		It("should return an error when the buffer is too small", func() {
+			_, c, err := VShareSecret(secp256k1.RandomFn(), randomIndices(n), n/2)
+			Expect(err).ToNot(HaveOccurred())
+			bs := make([]byte, c.SizeHint())
+			_, _, err = c.Marshal(bs, c.SizeHint())
+			Expect(err).ToNot(HaveOccurred())
+
+			var after Commitment
+			for i := 0; i < len(bs); i++ {
+				_, _, err = c.Marshal(bs[:i], c.SizeHint())
+				Expect(err).To(HaveOccurred())
+				_, _, err = after.Unmarshal(bs[:i], surge.MaxBytes)
+				Expect(err).To(HaveOccurred())
+			}
+		})
+
+		It("should return an error when unmarshaling non canonical points", func() {
+			_, c, err := VShareSecret(secp256k1.RandomFn(), randomIndices(n), 1)
+			Expect(err).ToNot(HaveOccurred())
+			bs, err := surge.ToBinary(c)
+			Expect(err).ToNot(HaveOccurred())
+
+			// The point at infinity with a non zero coordinate.
+			bs[surge.SizeHintU32] = 0xFF
+			var after Commitment
+			_, _, err = after.Unmarshal(bs, surge.MaxBytes)
+			Expect(err).To(BeAssignableToTypeOf(&secp256k1.NonCanonicalError{}))
+		})
+
+		It("should return an error when the length prefix is too large", func() {
+			var c Commitment
+			bs := []byte{0xFF, 0xFF, 0xFF, 0xFF}
+			_, _, err := c.Unmarshal(bs, surge.MaxBytes)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+func BenchmarkIsValid(b *testing.B) {
+	n := 100
+	shares, c, err := VShareSecret(secp256k1.RandomFn(), randomIndices(n), n/2)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = IsValid(c, &shares[i%n])
+	}
+}
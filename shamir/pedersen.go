@@ -0,0 +1,123 @@
+package shamir
+
+import (
+	"github.com/renproject/secp256k1"
+)
+
+// VerifiableShareSizeMarshalled is the number of bytes needed to represent a
+// marshalled verifiable share.
+const VerifiableShareSizeMarshalled int = ShareSizeMarshalled + secp256k1.FnSizeMarshalled
+
+// VerifiableShare represents a share of a Pedersen verifiable secret sharing.
+// It consists of a Shamir share of the secret together with the decommitment,
+// which is the share with the same index of the random blinding polynomial.
+type VerifiableShare struct {
+	Share        Share
+	Decommitment secp256k1.Fn
+}
+
+// Eq returns true if the two verifiable shares are equal, and false otherwise.
+func (vs *VerifiableShare) Eq(other *VerifiableShare) bool {
+	return vs.Share.Eq(&other.Share) && vs.Decommitment.Eq(&other.Decommitment)
+}
+
+// Add computes the sum of the two verifiable shares and stores the result in
+// the receiver. The result is valid for the sum of the commitments of the two
+// shares.
+//
+// Panics: This function will panic if the shares have different indices.
+func (vs *VerifiableShare) Add(a, b *VerifiableShare) {
+	vs.Share.Add(&a.Share, &b.Share)
+	vs.Decommitment.Add(&a.Decommitment, &b.Decommitment)
+}
+
+// Scale computes the scalar multiplication of the given verifiable share by
+// the given scalar and stores the result in the receiver. The result is valid
+// for the commitment of the share scaled by the same scalar.
+func (vs *VerifiableShare) Scale(a *VerifiableShare, scalar *secp256k1.Fn) {
+	vs.Share.Scale(&a.Share, scalar)
+	vs.Decommitment.Mul(&a.Decommitment, scalar)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (vs VerifiableShare) SizeHint() int { return VerifiableShareSizeMarshalled }
+
+// Marshal implements the surge.Marshaler interface.
+func (vs VerifiableShare) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := vs.Share.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return vs.Decommitment.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface. As for Share, the
+// fields are decoded strictly, and an error is returned if any of them is not
+// less than N.
+func (vs *VerifiableShare) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := vs.Share.Unmarshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return vs.Decommitment.UnmarshalStrict(buf, rem)
+}
+
+// PedersenShareSecret creates a k-out-of-n sharing of the given secret in the
+// same way as ShareSecret, and additionally returns the Pedersen commitment to
+// the sharing polynomial, which can be used to verify each of the shares with
+// IsValidPedersen. Unlike a Feldman commitment, the Pedersen commitment
+// reveals no information about the secret. The second generator used for the
+// commitment is the point returned by secp256k1.NewPointH.
+func PedersenShareSecret(secret secp256k1.Fn, indices []secp256k1.Fn, k int) ([]VerifiableShare, Commitment, error) {
+	shares, coeffs, err := shareSecret(secret, indices, k)
+	defer clearFns(coeffs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blinding, err := secp256k1.RandomFnNoPanic()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer blinding.Clear()
+	decommitments, blindingCoeffs, err := shareSecret(blinding, indices, k)
+	defer clearFns(blindingCoeffs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// c_i = a_i*G + b_i*H
+	var term secp256k1.Point
+	h := secp256k1.NewPointH()
+	commitment := make(Commitment, k)
+	for i := range commitment {
+		commitment[i].BaseExp(&coeffs[i])
+		term.Scale(&h, &blindingCoeffs[i])
+		commitment[i].Add(&commitment[i], &term)
+	}
+
+	vshares := make([]VerifiableShare, len(indices))
+	for i := range vshares {
+		vshares[i].Share = shares[i]
+		vshares[i].Decommitment = decommitments[i].Value
+	}
+
+	return vshares, commitment, nil
+}
+
+// IsValidPedersen returns true if the given verifiable share is consistent
+// with the given Pedersen commitment, and false otherwise. That is, it checks
+// that v*G + d*H is equal to the committed polynomial evaluated in the
+// exponent at the index of the share, where v is the value of the share and d
+// is its decommitment.
+func IsValidPedersen(c Commitment, vshare *VerifiableShare) bool {
+	if len(c) == 0 {
+		return false
+	}
+
+	var expected, actual secp256k1.Point
+	h := secp256k1.NewPointH()
+	c.Evaluate(&expected, &vshare.Share.Index)
+	actual.DoubleBaseExpVar(&vshare.Share.Value, &h, &vshare.Decommitment)
+	return actual.Eq(&expected)
+}
@@ -0,0 +1,256 @@
+package shamir_test
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/renproject/secp256k1"
+	. "github.com/renproject/secp256k1/shamir"
+)
+
+var _ = Describe("Pedersen verifiable secret sharing", func() {
+	trials := 20
+	n := 20
+
+	sharesOf := func(vshares []VerifiableShare) []Share {
+		shares := make([]Share, len(vshares))
+		for i := range vshares {
+			shares[i] = vshares[i].Share
+		}
+		return shares
+	}
+
+	Context("when sharing", func() {
+		It("should produce shares that are valid for the commitment", func() {
+			for i := 0; i < trials; i++ {
+				k := rand.Intn(n) + 1
+				vshares, c, err := PedersenShareSecret(secp256k1.RandomFn(), randomIndices(n), k)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(c.Threshold()).To(Equal(k))
+
+				for j := range vshares {
+					Expect(IsValidPedersen(c, &vshares[j])).To(BeTrue())
+				}
+			}
+		})
+
+		It("should produce shares that open to the secret", func() {
+			for i := 0; i < trials; i++ {
+				k := rand.Intn(n) + 1
+				secret := secp256k1.RandomFn()
+				vshares, _, err := PedersenShareSecret(secret, randomIndices(n), k)
+				Expect(err).ToNot(HaveOccurred())
+
+				opened, err := Open(randomSubset(sharesOf(vshares), k))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(opened.Eq(&secret)).To(BeTrue())
+			}
+		})
+
+		It("should not commit to the secret in the exponent", func() {
+			var g secp256k1.Point
+			for i := 0; i < trials; i++ {
+				secret := secp256k1.RandomFn()
+				_, c, err := PedersenShareSecret(secret, randomIndices(n), n/2)
+				Expect(err).ToNot(HaveOccurred())
+
+				g.BaseExp(&secret)
+				Expect(c[0].Eq(&g)).To(BeFalse())
+			}
+		})
+
+		It("should return an error for invalid arguments", func() {
+			indices := randomIndices(n)
+			_, _, err := PedersenShareSecret(secp256k1.RandomFn(), indices, 0)
+			Expect(err).To(HaveOccurred())
+			_, _, err = PedersenShareSecret(secp256k1.RandomFn(), indices, n+1)
+			Expect(err).To(HaveOccurred())
+
+			indices[1] = indices[0]
+			_, _, err = PedersenShareSecret(secp256k1.RandomFn(), indices, n/2)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when verifying", func() {
+		It("should reject shares with a modified value or decommitment", func() {
+			one := secp256k1.NewFnFromU16(1)
+			for i := 0; i < trials; i++ {
+				vshares, c, err := PedersenShareSecret(secp256k1.RandomFn(), randomIndices(n), rand.Intn(n)+1)
+				Expect(err).ToNot(HaveOccurred())
+
+				j := rand.Intn(n)
+				modified := vshares[j]
+				modified.Share.Value.Add(&modified.Share.Value, &one)
+				Expect(IsValidPedersen(c, &modified)).To(BeFalse())
+
+				modified = vshares[j]
+				modified.Decommitment.Add(&modified.Decommitment, &one)
+				Expect(IsValidPedersen(c, &modified)).To(BeFalse())
+			}
+		})
+
+		It("should reject shares for a different commitment", func() {
+			indices := randomIndices(n)
+			for i := 0; i < trials; i++ {
+				vshares, _, err := PedersenShareSecret(secp256k1.RandomFn(), indices, n/2)
+				Expect(err).ToNot(HaveOccurred())
+				_, c, err := PedersenShareSecret(secp256k1.RandomFn(), indices, n/2)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(IsValidPedersen(c, &vshares[rand.Intn(n)])).To(BeFalse())
+			}
+		})
+
+		It("should reject all shares for the empty commitment", func() {
+			vshares, _, err := PedersenShareSecret(secp256k1.RandomFn(), randomIndices(n), n/2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(IsValidPedersen(Commitment{}, &vshares[0])).To(BeFalse())
+		})
+	})
+
+	Context("when doing arithmetic", func() {
+		It("should produce valid shares of the sum of the secrets", func() {
+			for i := 0; i < trials; i++ {
+				k := rand.Intn(n) + 1
+				indices := randomIndices(n)
+				secret1, secret2 := secp256k1.RandomFn(), secp256k1.RandomFn()
+
+				vshares1, c1, err := PedersenShareSecret(secret1, indices, k)
+				Expect(err).ToNot(HaveOccurred())
+				vshares2, c2, err := PedersenShareSecret(secret2, indices, k)
+				Expect(err).ToNot(HaveOccurred())
+
+				var c Commitment
+				c.Add(c1, c2)
+				sum := make([]VerifiableShare, n)
+				for j := range sum {
+					sum[j].Add(&vshares1[j], &vshares2[j])
+					Expect(IsValidPedersen(c, &sum[j])).To(BeTrue())
+				}
+
+				var expected secp256k1.Fn
+				expected.Add(&secret1, &secret2)
+				opened, err := Open(randomSubset(sharesOf(sum), k))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(opened.Eq(&expected)).To(BeTrue())
+			}
+		})
+
+		It("should produce valid shares of the scaled secret", func() {
+			for i := 0; i < trials; i++ {
+				k := rand.Intn(n) + 1
+				secret, scalar := secp256k1.RandomFn(), secp256k1.RandomFn()
+
+				vshares, c, err := PedersenShareSecret(secret, randomIndices(n), k)
+				Expect(err).ToNot(HaveOccurred())
+
+				var scaledC Commitment
+				scaledC.Scale(c, &scalar)
+				scaled := make([]VerifiableShare, n)
+				for j := range scaled {
+					scaled[j].Scale(&vshares[j], &scalar)
+					Expect(IsValidPedersen(scaledC, &scaled[j])).To(BeTrue())
+				}
+
+				var expected secp256k1.Fn
+				expected.Mul(&secret, &scalar)
+				opened, err := Open(randomSubset(sharesOf(scaled), k))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(opened.Eq(&expected)).To(BeTrue())
+			}
+		})
+
+		It("should produce valid shares when scaling by zero", func() {
+			for i := 0; i < trials; i++ {
+				vshares, c, err := PedersenShareSecret(secp256k1.RandomFn(), randomIndices(n), rand.Intn(n)+1)
+				Expect(err).ToNot(HaveOccurred())
+
+				// Every point of the scaled commitment is at infinity.
+				var zero secp256k1.Fn
+				var scaledC Commitment
+				var scaled VerifiableShare
+				scaledC.Scale(c, &zero)
+				for j := range vshares {
+					scaled.Scale(&vshares[j], &zero)
+					Expect(IsValidPedersen(scaledC, &scaled)).To(BeTrue())
+				}
+			}
+		})
+
+		It("should scale Feldman commitments and shares", func() {
+			for i := 0; i < trials; i++ {
+				scalar := secp256k1.RandomFn()
+				shares, c, err := VShareSecret(secp256k1.RandomFn(), randomIndices(n), rand.Intn(n)+1)
+				Expect(err).ToNot(HaveOccurred())
+
+				var scaledC Commitment
+				var scaled Share
+				scaledC.Scale(c, &scalar)
+				for j := range shares {
+					scaled.Scale(&shares[j], &scalar)
+					Expect(IsValid(scaledC, &scaled)).To(BeTrue())
+				}
+			}
+		})
+
+		It("should panic when adding shares with different indices", func() {
+			var vshare VerifiableShare
+			vshares1, _, err := PedersenShareSecret(secp256k1.RandomFn(), randomIndices(n), n/2)
+			Expect(err).ToNot(HaveOccurred())
+			vshares2, _, err := PedersenShareSecret(secp256k1.RandomFn(), randomIndices(n), n/2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(func() { vshare.Add(&vshares1[0], &vshares2[0]) }).To(Panic())
+		})
+	})
+
+	Context("when marshalling", func() {
+		It("should be equal after marshaling and unmarshaling with surge", func() {
+			var bs [VerifiableShareSizeMarshalled]byte
+			var after VerifiableShare
+			for i := 0; i < trials; i++ {
+				vshares, _, err := PedersenShareSecret(secp256k1.RandomFn(), randomIndices(n), n/2)
+				Expect(err).ToNot(HaveOccurred())
+				before := vshares[rand.Intn(n)]
+
+				tail, rem, err := before.Marshal(bs[:], before.SizeHint())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rem).To(Equal(0))
+				Expect(len(tail)).To(Equal(0))
+
+				tail, rem, err = after.Unmarshal(bs[:], 3*secp256k1.FnSize)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rem).To(Equal(0))
+				Expect(len(tail)).To(Equal(0))
+
+				Expect(after.Eq(&before)).To(BeTrue())
+			}
+		})
+
+		It("should return an error when the buffer is too small", func() {
+			var bs [VerifiableShareSizeMarshalled - 1]byte
+			var vshare VerifiableShare
+			for i := 0; i < VerifiableShareSizeMarshalled-1; i++ {
+				_, _, err := vshare.Marshal(bs[:i], VerifiableShareSizeMarshalled)
+				Expect(err).To(HaveOccurred())
+				_, _, err = vshare.Unmarshal(bs[:i], 3*secp256k1.FnSize)
+				Expect(err).To(HaveOccurred())
+			}
+		})
+	})
+})
+
+func BenchmarkIsValidPedersen(b *testing.B) {
+	n := 100
+	vshares, c, err := PedersenShareSecret(secp256k1.RandomFn(), randomIndices(n), n/2)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = IsValidPedersen(c, &vshares[i%n])
+	}
+}
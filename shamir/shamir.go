@@ -0,0 +1,202 @@
+// Package shamir implements Shamir secret sharing over the secp256k1 scalar
+// field.
+package shamir
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/renproject/secp256k1"
+)
+
+// ShareSizeMarshalled is the number of bytes needed to represent a marshalled
+// share.
+const ShareSizeMarshalled int = 2 * secp256k1.FnSizeMarshalled
+
+// Share represents a Shamir share of a secret, which is the evaluation of the
+// sharing polynomial at the index of the share.
+type Share struct {
+	Index, Value secp256k1.Fn
+}
+
+// NewShare constructs a new share with the given index and value.
+func NewShare(index, value secp256k1.Fn) Share {
+	return Share{Index: index, Value: value}
+}
+
+// Eq returns true if the two shares have the same index and value, and false
+// otherwise.
+func (s *Share) Eq(other *Share) bool {
+	return s.Index.Eq(&other.Index) && s.Value.Eq(&other.Value)
+}
+
+// Add computes the sum of the two shares and stores the result in the
+// receiver. The result is a share of the sum of the two secrets.
+//
+// Panics: This function will panic if the shares have different indices.
+func (s *Share) Add(a, b *Share) {
+	if !a.Index.Eq(&b.Index) {
+		panic("expected shares to have the same index")
+	}
+
+	s.Index = a.Index
+	s.Value.Add(&a.Value, &b.Value)
+}
+
+// Scale computes the scalar multiplication of the given share by the given
+// scalar and stores the result in the receiver. The result is a share of the
+// secret multiplied by the scalar.
+func (s *Share) Scale(a *Share, scalar *secp256k1.Fn) {
+	s.Index = a.Index
+	s.Value.Mul(&a.Value, scalar)
+}
+
+// SizeHint implements the surge.SizeHinter interface.
+func (s Share) SizeHint() int { return ShareSizeMarshalled }
+
+// Marshal implements the surge.Marshaler interface.
+func (s Share) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := s.Index.Marshal(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return s.Value.Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface. Shares are usually
+// received from other parties, so the index and value are decoded strictly,
+// and an error is returned if either of them is not less than N.
+func (s *Share) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	buf, rem, err := s.Index.UnmarshalStrict(buf, rem)
+	if err != nil {
+		return buf, rem, err
+	}
+	return s.Value.UnmarshalStrict(buf, rem)
+}
+
+// ShareSecret creates a k-out-of-n sharing of the given secret, where n is the
+// number of indices, by sampling a random polynomial of degree k - 1 whose
+// constant term is the secret and evaluating it at each of the indices. Any k
+// of the resulting shares can be used to reconstruct the secret, while fewer
+// than k of them reveal no information about it.
+//
+// An error is returned if k is less than 1 or greater than the number of
+// indices, if any index is zero or if the indices are not distinct, or if
+// there was an error reading random bytes.
+func ShareSecret(secret secp256k1.Fn, indices []secp256k1.Fn, k int) ([]Share, error) {
+	shares, coeffs, err := shareSecret(secret, indices, k)
+	clearFns(coeffs)
+	return shares, err
+}
+
+// shareSecret is the same as ShareSecret, but additionally returns the
+// coefficients of the sharing polynomial in increasing order of degree. The
+// caller is responsible for clearing the coefficients.
+func shareSecret(secret secp256k1.Fn, indices []secp256k1.Fn, k int) ([]Share, []secp256k1.Fn, error) {
+	if err := checkIndices(indices, k); err != nil {
+		return nil, nil, err
+	}
+
+	coeffs := make([]secp256k1.Fn, k)
+	coeffs[0] = secret
+	for i := 1; i < k; i++ {
+		var err error
+		if coeffs[i], err = secp256k1.RandomFnNoPanic(); err != nil {
+			return nil, coeffs, err
+		}
+	}
+
+	shares := make([]Share, len(indices))
+	for i := range indices {
+		shares[i].Index = indices[i]
+		polyEval(&shares[i].Value, &indices[i], coeffs)
+	}
+
+	return shares, coeffs, nil
+}
+
+// Open reconstructs the secret from the given shares by Lagrange interpolation
+// at zero. The result is only the shared secret if at least k of the shares
+// from a k-out-of-n sharing are given; no error is returned if there are too
+// few shares. An error is returned if there are no shares, or if the indices
+// of the shares are not distinct.
+func Open(shares []Share) (secp256k1.Fn, error) {
+	if len(shares) == 0 {
+		return secp256k1.Fn{}, errors.New("no shares to open")
+	}
+
+	// The Lagrange coefficient of share i at zero is
+	//
+	//	l_i = prod_{j != i} x_j / (x_j - x_i)
+	//
+	// so the numerators and denominators are computed separately and all of
+	// the denominators are inverted together.
+	nums := make([]secp256k1.Fn, len(shares))
+	dens := make([]secp256k1.Fn, len(shares))
+	var diff secp256k1.Fn
+	for i := range shares {
+		nums[i] = secp256k1.NewFnFromU16(1)
+		dens[i] = secp256k1.NewFnFromU16(1)
+		for j := range shares {
+			if i == j {
+				continue
+			}
+			nums[i].Mul(&nums[i], &shares[j].Index)
+			diff.Negate(&shares[i].Index)
+			diff.Add(&diff, &shares[j].Index)
+			dens[i].Mul(&dens[i], &diff)
+		}
+		if dens[i].IsZero() {
+			return secp256k1.Fn{}, errors.New("share indices are not distinct")
+		}
+	}
+	secp256k1.BatchInverseFn(dens)
+
+	var secret, term secp256k1.Fn
+	for i := range shares {
+		term.Mul(&nums[i], &dens[i])
+		term.Mul(&term, &shares[i].Value)
+		secret.Add(&secret, &term)
+	}
+
+	return secret, nil
+}
+
+// checkIndices returns an error if the indices are not valid for a k-out-of-n
+// sharing.
+func checkIndices(indices []secp256k1.Fn, k int) error {
+	if k < 1 {
+		return fmt.Errorf("invalid threshold: expected at least 1, got %v", k)
+	}
+	if k > len(indices) {
+		return fmt.Errorf("invalid threshold: expected at most %v, got %v", len(indices), k)
+	}
+	for i := range indices {
+		if indices[i].IsZero() {
+			return errors.New("invalid index: zero")
+		}
+		for j := i + 1; j < len(indices); j++ {
+			if indices[i].Eq(&indices[j]) {
+				return errors.New("indices are not distinct")
+			}
+		}
+	}
+	return nil
+}
+
+// clearFns sets all of the given field elements to zero.
+func clearFns(xs []secp256k1.Fn) {
+	for i := range xs {
+		xs[i].Clear()
+	}
+}
+
+// polyEval evaluates the polynomial with the given coefficients, in increasing
+// order of degree, at the given point using Horner's method.
+func polyEval(dst, x *secp256k1.Fn, coeffs []secp256k1.Fn) {
+	*dst = coeffs[len(coeffs)-1]
+	for i := len(coeffs) - 2; i >= 0; i-- {
+		dst.Mul(dst, x)
+		dst.Add(dst, &coeffs[i])
+	}
+}
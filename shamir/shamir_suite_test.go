@@ -0,0 +1,13 @@
+package shamir_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestShamir(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Shamir Suite")
+}
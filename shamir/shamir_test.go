@@ -0,0 +1,253 @@
+package shamir_test
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/renproject/secp256k1"
+	. "github.com/renproject/secp256k1/shamir"
+	"github.com/renproject/surge"
+)
+
+// sequentialIndices returns the indices 1, ..., n.
+func sequentialIndices(n int) []secp256k1.Fn {
+	indices := make([]secp256k1.Fn, n)
+	for i := range indices {
+		indices[i] = secp256k1.NewFnFromU16(uint16(i + 1))
+	}
+	return indices
+}
+
+// randomIndices returns n distinct random indices.
+func randomIndices(n int) []secp256k1.Fn {
+	indices := make([]secp256k1.Fn, n)
+	for i := range indices {
+		indices[i] = secp256k1.RandomFn()
+	}
+	return indices
+}
+
+// randomSubset returns k of the given shares chosen at random.
+func randomSubset(shares []Share, k int) []Share {
+	subset := make([]Share, len(shares))
+	copy(subset, shares)
+	rand.Shuffle(len(subset), func(i, j int) {
+		subset[i], subset[j] = subset[j], subset[i]
+	})
+	return subset[:k]
+}
+
+var _ = Describe("Shamir secret sharing", func() {
+	trials := 20
+	n := 20
+
+	Context("when sharing and opening", func() {
+		It("should open to the secret with any k of the shares", func() {
+			for i := 0; i < trials; i++ {
+				k := rand.Intn(n) + 1
+				secret := secp256k1.RandomFn()
+
+				shares, err := ShareSecret(secret, randomIndices(n), k)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(len(shares)).To(Equal(n))
+
+				for j := k; j <= n; j++ {
+					opened, err := Open(randomSubset(shares, j))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(opened.Eq(&secret)).To(BeTrue())
+				}
+			}
+		})
+
+		It("should not open to the secret with fewer than k shares", func() {
+			for i := 0; i < trials; i++ {
+				k := rand.Intn(n-1) + 2
+				secret := secp256k1.RandomFn()
+
+				shares, err := ShareSecret(secret, sequentialIndices(n), k)
+				Expect(err).ToNot(HaveOccurred())
+
+				opened, err := Open(randomSubset(shares, k-1))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(opened.Eq(&secret)).To(BeFalse())
+			}
+		})
+
+		It("should give shares equal to the secret when k is 1", func() {
+			secret := secp256k1.RandomFn()
+			shares, err := ShareSecret(secret, sequentialIndices(n), 1)
+			Expect(err).ToNot(HaveOccurred())
+			for _, share := range shares {
+				Expect(share.Value.Eq(&secret)).To(BeTrue())
+			}
+		})
+
+		It("should give shares with the given indices", func() {
+			indices := randomIndices(n)
+			shares, err := ShareSecret(secp256k1.RandomFn(), indices, n/2)
+			Expect(err).ToNot(HaveOccurred())
+			for i, share := range shares {
+				Expect(share.Index.Eq(&indices[i])).To(BeTrue())
+			}
+		})
+
+		It("should be additively homomorphic", func() {
+			for i := 0; i < trials; i++ {
+				k := rand.Intn(n) + 1
+				indices := randomIndices(n)
+				secret1, secret2 := secp256k1.RandomFn(), secp256k1.RandomFn()
+
+				shares1, err := ShareSecret(secret1, indices, k)
+				Expect(err).ToNot(HaveOccurred())
+				shares2, err := ShareSecret(secret2, indices, k)
+				Expect(err).ToNot(HaveOccurred())
+
+				sum := make([]Share, n)
+				for j := range sum {
+					sum[j].Index = indices[j]
+					sum[j].Value.Add(&shares1[j].Value, &shares2[j].Value)
+				}
+
+				var expected secp256k1.Fn
+				expected.Add(&secret1, &secret2)
+				opened, err := Open(sum[:k])
+				Expect(err).ToNot(HaveOccurred())
+				Expect(opened.Eq(&expected)).To(BeTrue())
+			}
+		})
+	})
+
+	Context("when the arguments are invalid", func() {
+		It("should return an error when k is out of range", func() {
+			secret := secp256k1.RandomFn()
+			_, err := ShareSecret(secret, sequentialIndices(n), 0)
+			Expect(err).To(HaveOccurred())
+			_, err = ShareSecret(secret, sequentialIndices(n), -1)
+			Expect(err).To(HaveOccurred())
+			_, err = ShareSecret(secret, sequentialIndices(n), n+1)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error when an index is zero", func() {
+			indices := sequentialIndices(n)
+			indices[n/2].Clear()
+			_, err := ShareSecret(secp256k1.RandomFn(), indices, n/2)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error when the indices are not distinct", func() {
+			indices := randomIndices(n)
+			indices[n-1] = indices[0]
+			_, err := ShareSecret(secp256k1.RandomFn(), indices, n/2)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error when opening no shares", func() {
+			_, err := Open(nil)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error when opening shares with repeated indices", func() {
+			shares, err := ShareSecret(secp256k1.RandomFn(), randomIndices(n), n/2)
+			Expect(err).ToNot(HaveOccurred())
+			shares[1] = shares[0]
+			_, err = Open(shares)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when marshalling", func() {
+		It("should be equal after marshaling and unmarshaling with surge", func() {
+			var bs [ShareSizeMarshalled]byte
+			var after Share
+			for i := 0; i < trials; i++ {
+				before := NewShare(secp256k1.RandomFn(), secp256k1.RandomFn())
+
+				tail, rem, err := before.Marshal(bs[:], before.SizeHint())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rem).To(Equal(0))
+				Expect(len(tail)).To(Equal(0))
+
+				tail, rem, err = after.Unmarshal(bs[:], 2*secp256k1.FnSize)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rem).To(Equal(0))
+				Expect(len(tail)).To(Equal(0))
+
+				Expect(after.Eq(&before)).To(BeTrue())
+			}
+		})
+
+		It("should be equal after marshaling and unmarshaling a list of shares", func() {
+			for i := 0; i < trials; i++ {
+				before, err := ShareSecret(secp256k1.RandomFn(), randomIndices(n), n/2)
+				Expect(err).ToNot(HaveOccurred())
+
+				bs, err := surge.ToBinary(before)
+				Expect(err).ToNot(HaveOccurred())
+
+				var after []Share
+				Expect(surge.FromBinary(&after, bs)).To(Succeed())
+				Expect(len(after)).To(Equal(len(before)))
+				for j := range after {
+					Expect(after[j].Eq(&before[j])).To(BeTrue())
+				}
+			}
+		})
+
+		It("should return an error when the buffer is too small", func() {
+			var bs [ShareSizeMarshalled - 1]byte
+			var share Share
+			for i := 0; i < ShareSizeMarshalled-1; i++ {
+				_, _, err := share.Marshal(bs[:i], ShareSizeMarshalled)
+				Expect(err).To(HaveOccurred())
+				_, _, err = share.Unmarshal(bs[:i], 2*secp256k1.FnSize)
+				Expect(err).To(HaveOccurred())
+			}
+		})
+
+		It("should return an error when unmarshaling values that are not less than N", func() {
+			n := secp256k1.Curve().Params().N
+			for _, offset := range []int{0, secp256k1.FnSizeMarshalled} {
+				var bs [ShareSizeMarshalled]byte
+				bs[secp256k1.FnSizeMarshalled-1], bs[ShareSizeMarshalled-1] = 1, 1
+				n.FillBytes(bs[offset : offset+secp256k1.FnSizeMarshalled])
+
+				var share Share
+				_, _, err := share.Unmarshal(bs[:], 2*secp256k1.FnSize)
+				Expect(err).To(BeAssignableToTypeOf(&secp256k1.NonCanonicalError{}))
+			}
+		})
+	})
+})
+
+func benchmarkOpen(b *testing.B, n int) {
+	shares, err := ShareSecret(secp256k1.RandomFn(), randomIndices(n), n)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Open(shares)
+	}
+}
+
+func BenchmarkOpen10(b *testing.B) {
+	benchmarkOpen(b, 10)
+}
+
+func BenchmarkOpen100(b *testing.B) {
+	benchmarkOpen(b, 100)
+}
+
+func BenchmarkShareSecret100(b *testing.B) {
+	indices := randomIndices(100)
+	secret := secp256k1.RandomFn()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = ShareSecret(secret, indices, 50)
+	}
+}
@@ -0,0 +1,72 @@
+package secp256k1
+
+import "fmt"
+
+// NonCanonicalError is returned by the strict decoding functions when the
+// given bytes represent a number that is greater than or equal to the modulus
+// of the field, or more generally are not the unique encoding of a value.
+// Accepting such bytes would allow more than one encoding of the same value.
+type NonCanonicalError struct {
+	// Type is the name of the type that was being decoded, which is one of
+	// "Fn", "Fp" or "Point".
+	Type string
+}
+
+// Error implements the error interface.
+func (err *NonCanonicalError) Error() string {
+	return fmt.Sprintf("non canonical %v encoding: value is not less than the field modulus", err.Type)
+}
+
+// StrictFn is a field element whose surge Unmarshal method is
+// Fn.UnmarshalStrict, so that non canonical encodings are rejected rather than
+// reduced. It can be used in place of Fn in types that are decoded from
+// untrusted data, and a *Fn can be converted to a *StrictFn to strictly decode
+// a single value with surge.
+type StrictFn Fn
+
+// SizeHint implements the surge.SizeHinter interface.
+func (x StrictFn) SizeHint() int { return Fn(x).SizeHint() }
+
+// Marshal implements the surge.Marshaler interface.
+func (x StrictFn) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	return Fn(x).Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (x *StrictFn) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	return (*Fn)(x).UnmarshalStrict(buf, rem)
+}
+
+// StrictFp is a field element whose surge Unmarshal method is
+// Fp.UnmarshalStrict. See StrictFn.
+type StrictFp Fp
+
+// SizeHint implements the surge.SizeHinter interface.
+func (x StrictFp) SizeHint() int { return Fp(x).SizeHint() }
+
+// Marshal implements the surge.Marshaler interface.
+func (x StrictFp) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	return Fp(x).Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (x *StrictFp) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	return (*Fp)(x).UnmarshalStrict(buf, rem)
+}
+
+// StrictPoint is a curve point whose surge Unmarshal method is
+// Point.UnmarshalStrict. See StrictFn.
+type StrictPoint Point
+
+// SizeHint implements the surge.SizeHinter interface.
+func (p StrictPoint) SizeHint() int { return Point(p).SizeHint() }
+
+// Marshal implements the surge.Marshaler interface.
+func (p StrictPoint) Marshal(buf []byte, rem int) ([]byte, int, error) {
+	return Point(p).Marshal(buf, rem)
+}
+
+// Unmarshal implements the surge.Unmarshaler interface.
+func (p *StrictPoint) Unmarshal(buf []byte, rem int) ([]byte, int, error) {
+	return (*Point)(p).UnmarshalStrict(buf, rem)
+}